@@ -0,0 +1,85 @@
+package restapireceiver
+
+import (
+	"fmt"
+	"net/http"
+
+	"go.opentelemetry.io/collector/config/configopaque"
+)
+
+const (
+	API_KEY_IN_HEADER = "header"
+	API_KEY_IN_QUERY  = "query"
+
+	DEFAULT_API_KEY_HEADER = "X-API-Key"
+	DEFAULT_API_KEY_QUERY  = "api_key"
+)
+
+// APIKeyConfig sends an API key in a header (default X-API-Key) or a query parameter (default api_key),
+// after Prefix such as "Token ". The key is given as Key or read from KeyFile, again whenever it changes.
+type APIKeyConfig struct {
+	Key     configopaque.String `mapstructure:"key"`
+	KeyFile string              `mapstructure:"key_file"`
+	In      string              `mapstructure:"in"`
+	Name    string              `mapstructure:"name"`
+	Prefix  string              `mapstructure:"prefix"`
+}
+
+func (c *APIKeyConfig) validate() []string {
+	var errs []string
+	if (c.Key == "") == (c.KeyFile == "") {
+		errs = append(errs, "exactly one of 'auth.api_key.key' or 'auth.api_key.key_file' is required")
+	}
+	switch c.In {
+	case "", API_KEY_IN_HEADER, API_KEY_IN_QUERY:
+	default:
+		errs = append(errs, fmt.Sprintf("'auth.api_key.in' must be '%s' or '%s'", API_KEY_IN_HEADER, API_KEY_IN_QUERY))
+	}
+	return errs
+}
+
+// name returns the header or query parameter carrying the key
+func (c *APIKeyConfig) name() string {
+	switch {
+	case c.Name != "":
+		return c.Name
+	case c.In == API_KEY_IN_QUERY:
+		return DEFAULT_API_KEY_QUERY
+	}
+	return DEFAULT_API_KEY_HEADER
+}
+
+type apiKeySigner struct {
+	cfg  APIKeyConfig
+	name string
+	file *secretFile
+}
+
+func newAPIKeySigner(cfg *APIKeyConfig) *apiKeySigner {
+	s := &apiKeySigner{cfg: *cfg, name: cfg.name()}
+	if cfg.KeyFile != "" {
+		s.file = newSecretFile(cfg.KeyFile)
+	}
+	return s
+}
+
+func (s *apiKeySigner) Sign(req *http.Request, _ []byte) error {
+	key := s.cfg.Key
+	if s.file != nil {
+		// a key read before is kept while the file cannot be read
+		value, _, err := s.file.read()
+		if value == "" {
+			return fmt.Errorf("failed to read API key: %w", err)
+		}
+		key = value
+	}
+	value := s.cfg.Prefix + string(key)
+	if s.cfg.In == API_KEY_IN_QUERY {
+		query := req.URL.Query()
+		query.Set(s.name, value)
+		req.URL.RawQuery = query.Encode()
+		return nil
+	}
+	req.Header.Set(s.name, value)
+	return nil
+}
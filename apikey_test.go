@@ -0,0 +1,96 @@
+package restapireceiver
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestAPIKeySigner_Sign(t *testing.T) {
+	tests := []struct {
+		name   string
+		cfg    APIKeyConfig
+		header string
+		url    string
+	}{
+		{"default header", APIKeyConfig{Key: "k1"}, "k1", "https://api.example.com/v1?limit=10"},
+		{"header with prefix", APIKeyConfig{Key: "k1", Name: "Authorization", Prefix: "Token "}, "", "https://api.example.com/v1?limit=10"},
+		{"default query", APIKeyConfig{Key: "k1", In: API_KEY_IN_QUERY}, "", "https://api.example.com/v1?api_key=k1&limit=10"},
+		{"named query", APIKeyConfig{Key: "k 1", In: API_KEY_IN_QUERY, Name: "token"}, "", "https://api.example.com/v1?limit=10&token=k+1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "https://api.example.com/v1?limit=10", nil)
+			require.NoError(t, newAPIKeySigner(&tt.cfg).Sign(req, nil))
+			assert.Equal(t, tt.header, req.Header.Get(DEFAULT_API_KEY_HEADER))
+			assert.Equal(t, tt.url, req.URL.String())
+		})
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, "https://api.example.com/v1", nil)
+	require.NoError(t, newAPIKeySigner(&APIKeyConfig{Key: "k1", Name: "Authorization", Prefix: "Token "}).Sign(req, nil))
+	assert.Equal(t, "Token k1", req.Header.Get(HEADER_KEY_AUTHORIZATION))
+}
+
+func TestAPIKeySigner_KeyFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "key")
+	signer := newAPIKeySigner(&APIKeyConfig{KeyFile: path})
+	req, _ := http.NewRequest(http.MethodGet, "https://api.example.com/v1", nil)
+	assert.ErrorContains(t, signer.Sign(req, nil), "failed to read API key")
+
+	t0 := time.Now().Add(-time.Hour)
+	writeSecret(t, path, "k1\n", t0)
+	require.NoError(t, signer.Sign(req, nil))
+	assert.Equal(t, "k1", req.Header.Get(DEFAULT_API_KEY_HEADER))
+
+	writeSecret(t, path, "k2\n", t0.Add(time.Minute))
+	require.NoError(t, signer.Sign(req, nil))
+	assert.Equal(t, "k2", req.Header.Get(DEFAULT_API_KEY_HEADER))
+}
+
+func TestAPIKeyConfig_Validate(t *testing.T) {
+	assert.Empty(t, (&APIKeyConfig{Key: "k1"}).validate())
+	assert.Equal(t, []string{
+		"exactly one of 'auth.api_key.key' or 'auth.api_key.key_file' is required",
+		"'auth.api_key.in' must be 'header' or 'query'",
+	}, (&APIKeyConfig{Key: "k1", KeyFile: "/run/secrets/key", In: "cookie"}).validate())
+}
+
+func TestScraper_APIKeyRedacted(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "s3cr3t", r.URL.Query().Get("api_key"))
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	endpoint := server.URL
+	defer server.Close()
+
+	cfg := &Config{
+		Endpoint:    endpoint,
+		Auth:        AuthConfig{APIKey: &APIKeyConfig{Key: "s3cr3t", In: API_KEY_IN_QUERY}},
+		Retry:       RetryConfig{MaxAttempts: 2, RetryableStatusCodes: []int{http.StatusServiceUnavailable}},
+		Description: testClusterDescription,
+	}
+	require.NoError(t, cfg.Validate())
+	s := newTestScraper(t, cfg)
+	core, logs := observer.New(zap.DebugLevel)
+	s.logger = zap.New(core)
+	_, err := s.scrape(context.Background())
+	require.Error(t, err)
+	require.Equal(t, 1, logs.FilterMessage("retrying request").Len())
+	assert.Equal(t, endpoint+"/api/cluster?api_key=%5BREDACTED%5D", logs.All()[0].ContextMap()["url"])
+
+	// transport errors include the URL
+	server.Close()
+	_, err = s.scrape(context.Background())
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "s3cr3t")
+	assert.Contains(t, err.Error(), "api_key=%5BREDACTED%5D")
+}
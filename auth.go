@@ -0,0 +1,129 @@
+package restapireceiver
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"go.opentelemetry.io/collector/component"
+)
+
+// RequestSigner signs the requests sent by HttpClientHelper, typically by adding signature headers. Each attempt
+// is signed right before it is sent. body is the request body as it will be sent, empty when there is none.
+type RequestSigner interface {
+	Sign(req *http.Request, body []byte) error
+}
+
+// AuthConfig configures request authentication schemes besides auth_token and username / password
+// With Digest, username and password are sent using HTTP Digest authentication instead of basic auth.
+// Authenticator names a client authenticator extension of the collector (e.g. oauth2client) that
+// authenticates the requests instead.
+type AuthConfig struct {
+	HMAC          *HMACConfig   `mapstructure:"hmac"`
+	SigV4         *SigV4Config  `mapstructure:"sigv4"`
+	Digest        bool          `mapstructure:"digest"`
+	APIKey        *APIKeyConfig `mapstructure:"api_key"`
+	Authenticator component.ID  `mapstructure:"authenticator"`
+}
+
+// clientAuthenticator is implemented by the client authenticator extensions of the collector,
+// matching auth.Client of go.opentelemetry.io/collector/extension/auth
+type clientAuthenticator interface {
+	RoundTripper(base http.RoundTripper) (http.RoundTripper, error)
+}
+
+func (a *AuthConfig) validate() []string {
+	var errs []string
+	schemes := 0
+	for _, set := range []bool{a.HMAC != nil, a.SigV4 != nil, a.Digest, a.APIKey != nil, a.hasAuthenticator()} {
+		if set {
+			schemes++
+		}
+	}
+	if schemes > 1 {
+		errs = append(errs, "only one of 'auth.hmac', 'auth.sigv4', 'auth.digest', 'auth.api_key' or 'auth.authenticator' may be set")
+	}
+	if a.HMAC != nil {
+		errs = append(errs, a.HMAC.validate()...)
+	}
+	if a.SigV4 != nil {
+		errs = append(errs, a.SigV4.validate()...)
+	}
+	if a.APIKey != nil {
+		errs = append(errs, a.APIKey.validate()...)
+	}
+	return errs
+}
+
+// configured reports whether an auth scheme without token or username is set, which makes them optional
+func (a *AuthConfig) configured() bool {
+	return a.HMAC != nil || a.SigV4 != nil || a.APIKey != nil || a.hasAuthenticator()
+}
+
+// ownsAuthorization reports whether the configured scheme sets the Authorization header itself
+func (a *AuthConfig) ownsAuthorization() bool {
+	return a.SigV4 != nil || a.hasAuthenticator()
+}
+
+func (a *AuthConfig) hasAuthenticator() bool {
+	return a.Authenticator != component.ID{}
+}
+
+// authenticate wraps the transport with the configured authenticator extension, if any
+func (a *AuthConfig) authenticate(host component.Host, base http.RoundTripper) (http.RoundTripper, error) {
+	if !a.hasAuthenticator() {
+		return base, nil
+	}
+	ext, ok := host.GetExtensions()[a.Authenticator]
+	if !ok {
+		return nil, fmt.Errorf("authenticator '%s' not found", a.Authenticator)
+	}
+	authenticator, ok := ext.(clientAuthenticator)
+	if !ok {
+		return nil, fmt.Errorf("extension '%s' is not a client authenticator", a.Authenticator)
+	}
+	return authenticator.RoundTripper(base)
+}
+
+// sensitiveQueryParams returns the query parameters carrying credentials
+func (a *AuthConfig) sensitiveQueryParams() []string {
+	if a.APIKey != nil && a.APIKey.In == API_KEY_IN_QUERY {
+		return []string{a.APIKey.name()}
+	}
+	return nil
+}
+
+// signer returns the request signer of the configured scheme, nil if there is none
+func (a *AuthConfig) signer() (RequestSigner, error) {
+	switch {
+	case a.HMAC != nil:
+		return newHMACSigner(a.HMAC)
+	case a.SigV4 != nil:
+		return newSigV4Signer(a.SigV4), nil
+	case a.APIKey != nil:
+		return newAPIKeySigner(a.APIKey), nil
+	}
+	return nil, nil
+}
+
+// requestBody returns a copy of the body of a request, leaving the request readable
+func requestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	if req.GetBody == nil {
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		setBody(req, body)
+		return body, nil
+	}
+	rc, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
@@ -0,0 +1,98 @@
+package restapireceiver
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/confmap"
+	"go.opentelemetry.io/collector/receiver/receivertest"
+	"go.uber.org/zap"
+)
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// testAuthenticator behaves like the bearertokenauth extension
+type testAuthenticator struct {
+	component.StartFunc
+	component.ShutdownFunc
+	token string
+}
+
+func (a *testAuthenticator) RoundTripper(base http.RoundTripper) (http.RoundTripper, error) {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		req = req.Clone(req.Context())
+		req.Header.Set(HEADER_KEY_AUTHORIZATION, "Bearer "+a.token)
+		return base.RoundTrip(req)
+	}), nil
+}
+
+type nopExtension struct {
+	component.StartFunc
+	component.ShutdownFunc
+}
+
+type testHost struct {
+	component.Host
+	extensions map[component.ID]component.Component
+}
+
+func (h *testHost) GetExtensions() map[component.ID]component.Component {
+	return h.extensions
+}
+
+func TestScraper_Authenticator(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer t0k3n", r.Header.Get(HEADER_KEY_AUTHORIZATION))
+		w.Write([]byte(testClusterResponse))
+	}))
+	defer server.Close()
+
+	cfg := &Config{Endpoint: server.URL, Description: testClusterDescription}
+	require.NoError(t, confmap.NewFromStringMap(map[string]any{"auth": map[string]any{"authenticator": "bearertokenauth/api"}}).Unmarshal(cfg))
+	require.NoError(t, cfg.Validate())
+	host := &testHost{Host: componenttest.NewNopHost(), extensions: map[component.ID]component.Component{
+		component.MustNewIDWithName("bearertokenauth", "api"): &testAuthenticator{token: "t0k3n"},
+		component.MustNewID("health_check"):                   &nopExtension{},
+	}}
+
+	s := newScraper(zap.NewNop(), cfg, receivertest.NewNopCreateSettings())
+	require.NoError(t, s.start(context.Background(), host))
+	metrics, err := s.scrape(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 9, metrics.MetricCount())
+}
+
+func TestAuthConfig_AuthenticateErrors(t *testing.T) {
+	host := &testHost{Host: componenttest.NewNopHost(), extensions: map[component.ID]component.Component{
+		component.MustNewID("health_check"): &nopExtension{},
+	}}
+
+	a := &AuthConfig{Authenticator: component.MustNewID("oauth2client")}
+	_, err := a.authenticate(host, http.DefaultTransport)
+	assert.EqualError(t, err, "authenticator 'oauth2client' not found")
+
+	a = &AuthConfig{Authenticator: component.MustNewID("health_check")}
+	_, err = a.authenticate(host, http.DefaultTransport)
+	assert.EqualError(t, err, "extension 'health_check' is not a client authenticator")
+
+	transport, err := (&AuthConfig{}).authenticate(host, http.DefaultTransport)
+	require.NoError(t, err)
+	assert.Equal(t, http.DefaultTransport, transport)
+}
+
+func TestAuthConfig_Validate(t *testing.T) {
+	a := &AuthConfig{Digest: true, Authenticator: component.MustNewID("oauth2client")}
+	assert.Equal(t, []string{"only one of 'auth.hmac', 'auth.sigv4', 'auth.digest', 'auth.api_key' or 'auth.authenticator' may be set"}, a.validate())
+	assert.True(t, a.configured())
+	assert.False(t, (&AuthConfig{Digest: true}).configured())
+}
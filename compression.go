@@ -0,0 +1,141 @@
+package restapireceiver
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	HEADER_KEY_ACCEPT_ENCODING  = "Accept-Encoding"
+	HEADER_KEY_CONTENT_ENCODING = "Content-Encoding"
+
+	ENCODING_GZIP     = "gzip"
+	ENCODING_DEFLATE  = "deflate"
+	ENCODING_ZSTD     = "zstd"
+	ENCODING_BROTLI   = "br"
+	ENCODING_IDENTITY = "identity"
+
+	DEFAULT_MAX_RESPONSE_SIZE = 64 << 20
+)
+
+// contentDecoders decode response bodies by Content-Encoding; all of them are advertised in Accept-Encoding
+var contentDecoders = map[string]func(io.Reader) (io.ReadCloser, error){
+	ENCODING_GZIP: func(r io.Reader) (io.ReadCloser, error) { return gzip.NewReader(r) },
+	ENCODING_DEFLATE: func(r io.Reader) (io.ReadCloser, error) {
+		// "deflate" should be zlib wrapped, but some servers send raw deflate
+		br := bufio.NewReader(r)
+		if header, err := br.Peek(2); err == nil && isZlibHeader(header) {
+			return zlib.NewReader(br)
+		}
+		return flate.NewReader(br), nil
+	},
+	ENCODING_ZSTD: func(r io.Reader) (io.ReadCloser, error) {
+		// a single goroutine is enough for response bodies, and does not outlive Close
+		d, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+		return d.IOReadCloser(), nil
+	},
+	ENCODING_BROTLI: func(r io.Reader) (io.ReadCloser, error) { return io.NopCloser(brotli.NewReader(r)), nil },
+}
+
+// contentEncoders compress request bodies
+var contentEncoders = map[string]func(io.Writer) (io.WriteCloser, error){
+	ENCODING_GZIP:    func(w io.Writer) (io.WriteCloser, error) { return gzip.NewWriter(w), nil },
+	ENCODING_DEFLATE: func(w io.Writer) (io.WriteCloser, error) { return zlib.NewWriter(w), nil },
+	ENCODING_ZSTD: func(w io.Writer) (io.WriteCloser, error) {
+		return zstd.NewWriter(w, zstd.WithEncoderConcurrency(1))
+	},
+	ENCODING_BROTLI: func(w io.Writer) (io.WriteCloser, error) { return brotli.NewWriter(w), nil },
+}
+
+func isZlibHeader(b []byte) bool {
+	return b[0]&0x0f == 8 && (uint16(b[0])<<8|uint16(b[1]))%31 == 0
+}
+
+// acceptEncoding lists the supported response encodings
+func acceptEncoding() string {
+	encodings := make([]string, 0, len(contentDecoders))
+	for e := range contentDecoders {
+		encodings = append(encodings, e)
+	}
+	sort.Strings(encodings)
+	return strings.Join(encodings, ", ")
+}
+
+func validateRequestCompression(encoding string) []string {
+	if _, ok := contentEncoders[encoding]; encoding != "" && !ok {
+		return []string{fmt.Sprintf("unsupported 'request_compression' '%s'", encoding)}
+	}
+	return nil
+}
+
+// compressBody compresses the request body with the encoding
+func compressBody(req *http.Request, encoding string) error {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil
+	}
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	w, err := contentEncoders[encoding](&buf)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(body); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	setBody(req, buf.Bytes())
+	req.Header.Set(HEADER_KEY_CONTENT_ENCODING, encoding)
+	return nil
+}
+
+// readBody reads the decoded response body, failing when it is larger than maxSize bytes (0 for no limit).
+// The limit applies after decoding so that small compressed bodies cannot expand without bounds.
+func readBody(resp *http.Response, maxSize int64) ([]byte, error) {
+	if maxSize > 0 && resp.ContentLength > maxSize && resp.Header.Get(HEADER_KEY_CONTENT_ENCODING) == "" {
+		return nil, fmt.Errorf("response body of %d bytes exceeds max_response_size of %d bytes", resp.ContentLength, maxSize)
+	}
+	var body io.Reader = resp.Body
+	if encoding := strings.ToLower(strings.TrimSpace(resp.Header.Get(HEADER_KEY_CONTENT_ENCODING))); encoding != "" && encoding != ENCODING_IDENTITY {
+		decoder, ok := contentDecoders[encoding]
+		if !ok {
+			return nil, fmt.Errorf("unsupported response Content-Encoding '%s'", encoding)
+		}
+		decoded, err := decoder(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode %s response: %w", encoding, err)
+		}
+		defer decoded.Close()
+		body = decoded
+	}
+	if maxSize > 0 {
+		body = io.LimitReader(body, maxSize+1)
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+	if maxSize > 0 && int64(len(data)) > maxSize {
+		return nil, fmt.Errorf("response body exceeds max_response_size of %d bytes", maxSize)
+	}
+	return data, nil
+}
@@ -0,0 +1,142 @@
+package restapireceiver
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func compress(t *testing.T, encoding, data string) []byte {
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	switch encoding {
+	case ENCODING_GZIP:
+		w = gzip.NewWriter(&buf)
+	case ENCODING_DEFLATE:
+		w = zlib.NewWriter(&buf)
+	case "raw-deflate":
+		w, _ = flate.NewWriter(&buf, flate.DefaultCompression)
+	case ENCODING_ZSTD:
+		w, _ = zstd.NewWriter(&buf)
+	case ENCODING_BROTLI:
+		w = brotli.NewWriter(&buf)
+	}
+	_, err := w.Write([]byte(data))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func TestExecuteJsonRequest_CompressedResponse(t *testing.T) {
+	for _, tc := range []struct{ encoding, sent string }{
+		{ENCODING_GZIP, ENCODING_GZIP},
+		{ENCODING_DEFLATE, ENCODING_DEFLATE},
+		{ENCODING_DEFLATE, "raw-deflate"},
+		{ENCODING_ZSTD, ENCODING_ZSTD},
+		{ENCODING_BROTLI, ENCODING_BROTLI},
+	} {
+		t.Run(tc.sent, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "br, deflate, gzip, zstd", r.Header.Get(HEADER_KEY_ACCEPT_ENCODING))
+				w.Header().Set(HEADER_KEY_CONTENT_ENCODING, tc.encoding)
+				w.Write(compress(t, tc.sent, `{"key": "value"}`))
+			}))
+			defer server.Close()
+
+			helper := NewHttpClientHelper()
+			req, _ := helper.NewGetRequest(server.URL)
+			response, err := helper.ExecuteJsonRequest(req)
+			require.NoError(t, err)
+			assert.Equal(t, map[string]any{"key": "value"}, response)
+		})
+	}
+}
+
+func TestExecuteJsonRequest_UnsupportedEncoding(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(HEADER_KEY_CONTENT_ENCODING, "compress")
+		w.Write([]byte("..."))
+	}))
+	defer server.Close()
+
+	helper := NewHttpClientHelper()
+	req, _ := helper.NewGetRequest(server.URL)
+	_, err := helper.ExecuteJsonRequest(req)
+	assert.EqualError(t, err, "unsupported response Content-Encoding 'compress'")
+}
+
+func TestExecuteJsonRequest_MaxResponseSize(t *testing.T) {
+	large := `{"key": "` + strings.Repeat("x", 1000) + `"}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/gzip" {
+			w.Header().Set(HEADER_KEY_CONTENT_ENCODING, ENCODING_GZIP)
+			w.Write(compress(t, ENCODING_GZIP, large))
+			return
+		}
+		w.Write([]byte(large))
+	}))
+	defer server.Close()
+
+	helper := NewHttpClientHelper()
+	helper.MaxResponseSize = 100
+	req, _ := helper.NewGetRequest(server.URL)
+	_, err := helper.ExecuteJsonRequest(req)
+	assert.EqualError(t, err, "response body of 1011 bytes exceeds max_response_size of 100 bytes")
+
+	// the compressed body is small, the limit applies to the decoded one
+	req, _ = helper.NewGetRequest(server.URL + "/gzip")
+	_, err = helper.ExecuteJsonRequest(req)
+	assert.EqualError(t, err, "response body exceeds max_response_size of 100 bytes")
+
+	helper.MaxResponseSize = 2000
+	req, _ = helper.NewGetRequest(server.URL + "/gzip")
+	_, err = helper.ExecuteJsonRequest(req)
+	assert.NoError(t, err)
+}
+
+func TestNewRequest_RequestCompression(t *testing.T) {
+	for _, encoding := range []string{ENCODING_ZSTD, ENCODING_BROTLI} {
+		helper := NewHttpClientHelper()
+		helper.RequestCompression = encoding
+		req, err := helper.NewPostJsonRequest("http://example.com", `{"type": "volume"}`)
+		require.NoError(t, err)
+		assert.Equal(t, encoding, req.Header.Get(HEADER_KEY_CONTENT_ENCODING))
+		resp := &http.Response{Header: req.Header, Body: req.Body, ContentLength: -1}
+		decoded, err := readBody(resp, 0)
+		require.NoError(t, err)
+		assert.Equal(t, `{"type": "volume"}`, string(decoded))
+	}
+
+	helper := NewHttpClientHelper()
+	helper.RequestCompression = ENCODING_GZIP
+	req, err := helper.NewPostJsonRequest("http://example.com", `{"type": "volume"}`)
+	require.NoError(t, err)
+	assert.Equal(t, ENCODING_GZIP, req.Header.Get(HEADER_KEY_CONTENT_ENCODING))
+
+	for _, body := range []func() (io.ReadCloser, error){
+		func() (io.ReadCloser, error) { return req.Body, nil },
+		req.GetBody,
+	} {
+		rc, err := body()
+		require.NoError(t, err)
+		gz, err := gzip.NewReader(rc)
+		require.NoError(t, err)
+		decoded, _ := io.ReadAll(gz)
+		assert.Equal(t, `{"type": "volume"}`, string(decoded))
+	}
+
+	req, err = helper.NewGetRequest("http://example.com")
+	require.NoError(t, err)
+	assert.Empty(t, req.Header.Get(HEADER_KEY_CONTENT_ENCODING))
+}
@@ -2,31 +2,95 @@ package restapireceiver
 
 import (
 	"fmt"
+	"go.opentelemetry.io/collector/config/configopaque"
 	"go.opentelemetry.io/collector/receiver/scraperhelper"
 	"strings"
 )
 
 type Config struct {
 	scraperhelper.ControllerConfig `mapstructure:",squash"`
-	Endpoint                       string `mapstructure:"endpoint"`
-	AuthToken                      string `mapstructure:"auth_token"`
-	Username                       string `mapstructure:"username"`
-	Password                       string `mapstructure:"password"`
+	Endpoint                       string              `mapstructure:"endpoint"`
+	EndpointTemplate               string              `mapstructure:"endpoint_template"`
+	AuthToken                      configopaque.String `mapstructure:"auth_token"`
+	AuthTokenFile                  string              `mapstructure:"auth_token_file"`
+	Username                       string              `mapstructure:"username"`
+	Password                       configopaque.String `mapstructure:"password"`
+	PasswordFile                   string              `mapstructure:"password_file"`
+	Auth                           AuthConfig          `mapstructure:"auth"`
+	Profile                        string              `mapstructure:"profile"`
+	Description                    Description         `mapstructure:"description"`
+	Retry                          RetryConfig         `mapstructure:"retry"`
+	RateLimit                      RateLimitConfig     `mapstructure:"rate_limit"`
+	ConditionalRequests            bool                `mapstructure:"conditional_requests"`
+	RequestCompression             string              `mapstructure:"request_compression"`
+	MaxResponseSize                int64               `mapstructure:"max_response_size"`
+	Proxy                          ProxyConfig         `mapstructure:"proxy"`
+	RequestMetrics                 bool                `mapstructure:"request_metrics"`
+	Discovery                      DiscoveryConfig     `mapstructure:"discovery"`
+
+	loadedProfile *loadedProfile
 }
 
 func (c *Config) Validate() error {
 	var validationErrors []string = []string{}
 
-	if c.Endpoint == "" {
+	if c.Discovery.enabled() {
+		if c.Endpoint != "" {
+			validationErrors = append(validationErrors, "'endpoint' cannot be combined with 'discovery'")
+		}
+	} else if c.Endpoint == "" {
 		validationErrors = append(validationErrors, "'endpoint' is required")
 	}
+	if c.EndpointTemplate != "" && c.Discovery.enabled() {
+		validationErrors = append(validationErrors, "'endpoint_template' cannot be combined with 'discovery'")
+	}
+	endpoint, err := c.resolveEndpoint()
+	if err != nil {
+		validationErrors = append(validationErrors, fmt.Sprintf("'endpoint_template': %v", err))
+	}
+	validationErrors = append(validationErrors, validateEndpoint(endpoint)...)
 
-	if c.AuthToken == "" {
-		if c.Username == "" || c.Password == "" {
+	// local socket APIs usually do not authenticate
+	_, isUnixSocket := unixSocketPath(endpoint)
+	if c.AuthToken != "" && c.AuthTokenFile != "" {
+		validationErrors = append(validationErrors, "only one of 'auth_token' or 'auth_token_file' may be set")
+	}
+	if c.Password != "" && c.PasswordFile != "" {
+		validationErrors = append(validationErrors, "only one of 'password' or 'password_file' may be set")
+	}
+	if c.Auth.ownsAuthorization() && (c.AuthToken != "" || c.AuthTokenFile != "" || c.Username != "") {
+		validationErrors = append(validationErrors, "'auth_token' and 'username' cannot be combined with 'auth.sigv4' or 'auth.authenticator'")
+	}
+	if c.Auth.Digest && (c.AuthToken != "" || c.AuthTokenFile != "") {
+		validationErrors = append(validationErrors, "'auth.digest' uses 'username' and 'password' and cannot be combined with 'auth_token'")
+	}
+	// discovered targets may reference credentials of their own
+	if c.AuthToken == "" && c.AuthTokenFile == "" && !isUnixSocket && !c.Auth.configured() && !c.Discovery.enabled() {
+		if c.Username == "" || (c.Password == "" && c.PasswordFile == "") {
 			validationErrors = append(validationErrors, "either of 'auth_token' or 'username'+'password' are required")
 		}
 	}
 
+	validationErrors = append(validationErrors, c.Auth.validate()...)
+	validationErrors = append(validationErrors, c.Retry.validate()...)
+	validationErrors = append(validationErrors, c.RateLimit.validate()...)
+	validationErrors = append(validationErrors, c.Proxy.validate()...)
+	validationErrors = append(validationErrors, c.Discovery.validate()...)
+	validationErrors = append(validationErrors, validateRequestCompression(c.RequestCompression)...)
+	if c.MaxResponseSize < 0 {
+		validationErrors = append(validationErrors, "'max_response_size' must not be negative")
+	}
+	description, err := c.resolveDescription()
+	if err != nil {
+		validationErrors = append(validationErrors, fmt.Sprintf("'profile': %v", err))
+	}
+	validationErrors = append(validationErrors, description.validate()...)
+	for i, ep := range description.Endpoints {
+		if ep.CollectionInterval > 0 && ep.CollectionInterval < c.CollectionInterval {
+			validationErrors = append(validationErrors, fmt.Sprintf("endpoints[%d]: 'collection_interval' must not be shorter than the receiver's", i))
+		}
+	}
+
 	if len(validationErrors) > 0 {
 		return fmt.Errorf("Config validation failed: %v", strings.Join(validationErrors, ", "))
 	}
@@ -2,7 +2,9 @@ package restapireceiver
 
 import (
 	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/collector/receiver/scraperhelper"
 	"testing"
+	"time"
 )
 
 func TestValidate(t *testing.T) {
@@ -46,6 +48,67 @@ func TestValidate(t *testing.T) {
 			wantErr: true,
 			errMsg:  "Config validation failed: either of 'auth_token' or 'username'+'password' are required",
 		},
+		{
+			name: "InvalidDescription",
+			config: Config{Endpoint: "http://example.com", AuthToken: "someAuthToken", Description: Description{
+				Endpoints: []EndpointDescription{{Resources: []ResourceDescription{{
+					Attributes: map[string]string{"name": "name"},
+					Metrics:    []MetricDescription{{Name: "ratio", Expression: "used /"}},
+				}}}},
+			}},
+			wantErr: true,
+			errMsg:  "Config validation failed: endpoints[0].resources[0].metrics[0]: expression 'used /': unexpected end of expression",
+		},
+		{
+			name: "EndpointIntervalShorterThanReceiver",
+			config: Config{
+				ControllerConfig: scraperhelper.ControllerConfig{CollectionInterval: time.Minute},
+				Endpoint:         "http://example.com", AuthToken: "someAuthToken",
+				Description: Description{Endpoints: []EndpointDescription{{Path: "/perf", CollectionInterval: 10 * time.Second}}},
+			},
+			wantErr: true,
+			errMsg:  "Config validation failed: endpoints[0]: 'collection_interval' must not be shorter than the receiver's",
+		},
+		{
+			name:    "ValidConfigWithSecretFiles",
+			config:  Config{Endpoint: "http://example.com", Username: "user", PasswordFile: "/run/secrets/password"},
+			wantErr: false,
+		},
+		{
+			name:    "ValidConfigWithHMAC",
+			config:  Config{Endpoint: "http://example.com", Auth: AuthConfig{HMAC: &HMACConfig{Secret: "s3cr3t"}}},
+			wantErr: false,
+		},
+		{
+			name:    "SigV4WithAuthToken",
+			config:  Config{Endpoint: "http://example.com", AuthToken: "someAuthToken", Auth: AuthConfig{SigV4: &testSigV4Config}},
+			wantErr: true,
+			errMsg:  "Config validation failed: 'auth_token' and 'username' cannot be combined with 'auth.sigv4' or 'auth.authenticator'",
+		},
+		{
+			name:    "DigestWithAuthToken",
+			config:  Config{Endpoint: "http://example.com", AuthToken: "someAuthToken", Auth: AuthConfig{Digest: true}},
+			wantErr: true,
+			errMsg:  "Config validation failed: 'auth.digest' uses 'username' and 'password' and cannot be combined with 'auth_token'",
+		},
+		{
+			name:    "DigestWithoutPassword",
+			config:  Config{Endpoint: "http://example.com", Username: "admin", Auth: AuthConfig{Digest: true}},
+			wantErr: true,
+			errMsg:  "Config validation failed: either of 'auth_token' or 'username'+'password' are required",
+		},
+		{
+			name:    "TokenAndTokenFile",
+			config:  Config{Endpoint: "http://example.com", AuthToken: "someAuthToken", AuthTokenFile: "/run/secrets/token"},
+			wantErr: true,
+			errMsg:  "Config validation failed: only one of 'auth_token' or 'auth_token_file' may be set",
+		},
+		{
+			name:    "InvalidCompressionAndResponseSize",
+			config:  Config{Endpoint: "http://example.com", AuthToken: "someAuthToken", RequestCompression: "lz4", MaxResponseSize: -1},
+			wantErr: true,
+			errMsg:  "Config validation failed: unsupported 'request_compression' 'lz4', 'max_response_size' must not be negative",
+		},
 	}
 
 	for _, tt := range tests {
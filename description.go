@@ -0,0 +1,247 @@
+package restapireceiver
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	VALUE_TYPE_INT    = "int"
+	VALUE_TYPE_DOUBLE = "double"
+
+	METRIC_TYPE_GAUGE = "gauge"
+	METRIC_TYPE_SUM   = "sum"
+)
+
+// Description describes which REST API endpoints to call and how their JSON responses map to metrics
+type Description struct {
+	Endpoints []EndpointDescription `mapstructure:"endpoints"`
+}
+
+// EndpointDescription describes a single API call relative to Config.Endpoint.
+// Name identifies the endpoint when overriding the endpoints of a profile, which are otherwise matched by Path.
+// CollectionInterval polls the endpoint less often than the receiver's collection_interval.
+// Method defaults to GET; Body (JSON), Form and Query values are Go templates, see requestTemplateData.
+// With GraphQL, the query is POSTed to Path instead and selectors apply to the `data` of the response.
+type EndpointDescription struct {
+	Name               string                `mapstructure:"name"`
+	Path               string                `mapstructure:"path"`
+	Method             string                `mapstructure:"method"`
+	Body               string                `mapstructure:"body"`
+	Form               map[string]string     `mapstructure:"form"`
+	Query              map[string]string     `mapstructure:"query"`
+	GraphQL            *GraphQLDescription   `mapstructure:"graphql"`
+	CollectionInterval time.Duration         `mapstructure:"collection_interval"`
+	Resources          []ResourceDescription `mapstructure:"resources"`
+}
+
+// ResourceDescription maps objects selected from a response to resources.
+// Selector picks the objects (e.g. `nodes[*]`), empty means the whole response.
+// Attributes and metric fields are selectors relative to each selected object, or to the root with `$.`.
+// Timestamp and StartTime apply to all metrics of the resource unless a metric sets its own.
+type ResourceDescription struct {
+	Selector   string                `mapstructure:"selector"`
+	Attributes map[string]string     `mapstructure:"attributes"`
+	Timestamp  *TimestampDescription `mapstructure:"timestamp"`
+	StartTime  *TimestampDescription `mapstructure:"start_time"`
+	Metrics    []MetricDescription   `mapstructure:"metrics"`
+}
+
+// MetricDescription describes one metric of a resource. The value is read from Field, or derived
+// from Expression evaluated after all field metrics of the same object have been extracted.
+// Field values are converted from SourceUnit (or the unit of a quantity string like "12.5 GiB") to Unit.
+// Type is gauge (default) or sum; sums are cumulative and start at StartTime, or at the receiver start.
+// With Series, the value is an array of timestamped samples instead of a single field.
+type MetricDescription struct {
+	Name       string                `mapstructure:"name"`
+	Unit       string                `mapstructure:"unit"`
+	SourceUnit string                `mapstructure:"source_unit"`
+	Type       string                `mapstructure:"type"`
+	Monotonic  bool                  `mapstructure:"monotonic"`
+	ValueType  string                `mapstructure:"value_type"`
+	Field      string                `mapstructure:"field"`
+	Expression string                `mapstructure:"expression"`
+	States     *StateMapping         `mapstructure:"states"`
+	Series     *SeriesDescription    `mapstructure:"series"`
+	Timestamp  *TimestampDescription `mapstructure:"timestamp"`
+	StartTime  *TimestampDescription `mapstructure:"start_time"`
+}
+
+type compiledDescription struct {
+	endpoints []*compiledEndpoint
+}
+
+type compiledEndpoint struct {
+	EndpointDescription
+	request   *compiledRequest
+	graphql   *compiledGraphQL
+	resources []*compiledResource
+}
+
+type compiledResource struct {
+	selector   selector
+	attributes map[string]selector
+	timestamp  *compiledTimestamp
+	startTime  *compiledTimestamp
+	metrics    []*compiledMetric
+}
+
+type compiledMetric struct {
+	MetricDescription
+	field     selector
+	expr      *expression
+	states    *compiledStateMapping
+	series    *compiledSeries
+	timestamp *compiledTimestamp
+	startTime *compiledTimestamp
+}
+
+// validate returns the list of problems found in the description
+func (d *Description) validate() []string {
+	_, errs := compileDescription(d)
+	return errs
+}
+
+// compileDescription parses all selectors and expressions of the description
+func compileDescription(d *Description) (*compiledDescription, []string) {
+	var errs []string
+	cd := &compiledDescription{}
+	for i, ep := range d.Endpoints {
+		prefix := fmt.Sprintf("endpoints[%d]", i)
+		ce := &compiledEndpoint{EndpointDescription: ep}
+		if ep.CollectionInterval < 0 {
+			errs = append(errs, fmt.Sprintf("%s: 'collection_interval' must not be negative", prefix))
+		}
+		var reqErrs []string
+		ce.request, reqErrs = compileRequest(&ep)
+		for _, e := range reqErrs {
+			errs = append(errs, fmt.Sprintf("%s: %s", prefix, e))
+		}
+		if ep.GraphQL != nil {
+			ce.graphql, reqErrs = compileGraphQL(&ep)
+			for _, e := range reqErrs {
+				errs = append(errs, fmt.Sprintf("%s: %s", prefix, e))
+			}
+		}
+		for j, res := range ep.Resources {
+			cr, resErrs := compileResource(fmt.Sprintf("%s.resources[%d]", prefix, j), res)
+			errs = append(errs, resErrs...)
+			ce.resources = append(ce.resources, cr)
+		}
+		cd.endpoints = append(cd.endpoints, ce)
+	}
+	return cd, errs
+}
+
+func compileResource(prefix string, res ResourceDescription) (*compiledResource, []string) {
+	var errs []string
+	cr := &compiledResource{attributes: make(map[string]selector)}
+	var err error
+	if cr.selector, err = parseSelector(res.Selector); err != nil {
+		errs = append(errs, fmt.Sprintf("%s: %v", prefix, err))
+	}
+	if len(res.Attributes) == 0 {
+		errs = append(errs, fmt.Sprintf("%s: at least one attribute is required", prefix))
+	}
+	for name, path := range res.Attributes {
+		sel, err := parseSelector(path)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s.attributes.%s: %v", prefix, name, err))
+		}
+		cr.attributes[name] = sel
+	}
+	cr.timestamp, errs = compileOptionalTimestamp(prefix+".timestamp", res.Timestamp, errs)
+	cr.startTime, errs = compileOptionalTimestamp(prefix+".start_time", res.StartTime, errs)
+	names := make(map[string]bool)
+	for k, m := range res.Metrics {
+		mPrefix := fmt.Sprintf("%s.metrics[%d]", prefix, k)
+		cm := &compiledMetric{MetricDescription: m}
+		if m.Name == "" {
+			errs = append(errs, fmt.Sprintf("%s: 'name' is required", mPrefix))
+		} else if names[m.Name] {
+			errs = append(errs, fmt.Sprintf("%s: duplicate metric name '%s'", mPrefix, m.Name))
+		}
+		names[m.Name] = true
+		switch m.Type {
+		case "", METRIC_TYPE_GAUGE:
+			if m.StartTime != nil || m.Monotonic {
+				errs = append(errs, fmt.Sprintf("%s: 'start_time' and 'monotonic' require type '%s'", mPrefix, METRIC_TYPE_SUM))
+			}
+		case METRIC_TYPE_SUM:
+			if m.States != nil && m.States.Mode == STATE_MODE_STATE_SET {
+				errs = append(errs, fmt.Sprintf("%s: state_set metrics must be gauges", mPrefix))
+			}
+		default:
+			errs = append(errs, fmt.Sprintf("%s: unknown type '%s'", mPrefix, m.Type))
+		}
+		cm.timestamp, errs = compileOptionalTimestamp(mPrefix+".timestamp", m.Timestamp, errs)
+		cm.startTime, errs = compileOptionalTimestamp(mPrefix+".start_time", m.StartTime, errs)
+		switch m.ValueType {
+		case "", VALUE_TYPE_INT, VALUE_TYPE_DOUBLE:
+		default:
+			errs = append(errs, fmt.Sprintf("%s: unknown value_type '%s'", mPrefix, m.ValueType))
+		}
+		if m.SourceUnit != "" {
+			if m.Field == "" && m.Series == nil {
+				errs = append(errs, fmt.Sprintf("%s: 'source_unit' requires 'field'", mPrefix))
+			} else if _, ok := lookupUnit(m.SourceUnit); !ok {
+				errs = append(errs, fmt.Sprintf("%s: unknown source_unit '%s'", mPrefix, m.SourceUnit))
+			} else if m.Unit != "" {
+				if err := checkUnitConversion(m.SourceUnit, m.Unit); err != nil {
+					errs = append(errs, fmt.Sprintf("%s: %v", mPrefix, err))
+				}
+			}
+		}
+		if m.States != nil {
+			if (m.Field == "" && m.Series == nil) || m.SourceUnit != "" {
+				errs = append(errs, fmt.Sprintf("%s: 'states' requires 'field' and no 'source_unit'", mPrefix))
+			}
+			var stateErrs []string
+			cm.states, stateErrs = compileStateMapping(m.States)
+			for _, e := range stateErrs {
+				errs = append(errs, fmt.Sprintf("%s.states: %s", mPrefix, e))
+			}
+		}
+		if m.Series != nil {
+			if m.Timestamp != nil || (m.States != nil && m.States.Mode == STATE_MODE_STATE_SET) {
+				errs = append(errs, fmt.Sprintf("%s: 'series' cannot be combined with 'timestamp' or state_set", mPrefix))
+			}
+			var seriesErrs []string
+			cm.series, seriesErrs = compileSeries(m.Series)
+			for _, e := range seriesErrs {
+				errs = append(errs, fmt.Sprintf("%s.series: %s", mPrefix, e))
+			}
+		}
+		switch {
+		case m.Series != nil:
+			if m.Field != "" || m.Expression != "" {
+				errs = append(errs, fmt.Sprintf("%s: 'series' cannot be combined with 'field' or 'expression'", mPrefix))
+			}
+		case m.Field != "" && m.Expression != "":
+			errs = append(errs, fmt.Sprintf("%s: only one of 'field' or 'expression' may be set", mPrefix))
+		case m.Field != "":
+			if cm.field, err = parseSelector(m.Field); err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %v", mPrefix, err))
+			}
+		case m.Expression != "":
+			if cm.expr, err = compileExpression(m.Expression); err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %v", mPrefix, err))
+			}
+		default:
+			errs = append(errs, fmt.Sprintf("%s: one of 'field', 'expression' or 'series' is required", mPrefix))
+		}
+		cr.metrics = append(cr.metrics, cm)
+	}
+	return cr, errs
+}
+
+func compileOptionalTimestamp(prefix string, td *TimestampDescription, errs []string) (*compiledTimestamp, []string) {
+	if td == nil {
+		return nil, errs
+	}
+	ct, tsErrs := compileTimestamp(td)
+	for _, e := range tsErrs {
+		errs = append(errs, fmt.Sprintf("%s: %s", prefix, e))
+	}
+	return ct, errs
+}
@@ -0,0 +1,243 @@
+package restapireceiver
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+const (
+	HEADER_KEY_WWW_AUTHENTICATE = "WWW-Authenticate"
+
+	DIGEST_QOP_AUTH     = "auth"
+	DIGEST_QOP_AUTH_INT = "auth-int"
+)
+
+// digestChallenge holds the parameters of a Digest WWW-Authenticate challenge
+type digestChallenge struct {
+	realm     string
+	nonce     string
+	opaque    string
+	algorithm string
+	qop       string // chosen quality of protection, empty for RFC 2069 servers
+	stale     bool
+	nc        int // number of requests sent with the nonce
+}
+
+// digestTransport answers HTTP Digest challenges (RFC 7616). The last challenge is reused for the following
+// requests with an increasing nonce count, so only the first request and nonce changes cost a round trip.
+type digestTransport struct {
+	base     http.RoundTripper
+	mu       sync.Mutex
+	username string
+	password string
+	current  *digestChallenge
+}
+
+func newDigestTransport(base http.RoundTripper) *digestTransport {
+	return &digestTransport{base: base}
+}
+
+// setCredentials sets the credentials used from the next request on
+func (t *digestTransport) setCredentials(username, password string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.username, t.password = username, password
+}
+
+func (t *digestTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := requestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	attempt, err := t.authorize(req, body)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := t.base.RoundTrip(attempt)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	challenge := parseDigestChallenge(resp.Header.Values(HEADER_KEY_WWW_AUTHENTICATE))
+	if challenge == nil {
+		return resp, nil
+	}
+
+	t.mu.Lock()
+	previous := t.current
+	t.current = challenge
+	t.mu.Unlock()
+	// credentials sent for the same nonce were rejected, unless the server reports the nonce as stale
+	if previous != nil && previous.nonce == challenge.nonce && !challenge.stale {
+		return resp, nil
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	attempt, err = t.authorize(req, body)
+	if err != nil {
+		return nil, err
+	}
+	return t.base.RoundTrip(attempt)
+}
+
+// authorize clones the request with an Authorization for the current challenge, if there is one
+func (t *digestTransport) authorize(req *http.Request, body []byte) (*http.Request, error) {
+	attempt := req.Clone(req.Context())
+	if body != nil {
+		setBody(attempt, body)
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.current == nil {
+		return attempt, nil
+	}
+	t.current.nc++
+	cnonce, err := newCnonce()
+	if err != nil {
+		return nil, err
+	}
+	authorization, err := t.current.authorization(t.username, t.password, req.Method, req.URL.RequestURI(), body, cnonce)
+	if err != nil {
+		return nil, err
+	}
+	attempt.Header.Set(HEADER_KEY_AUTHORIZATION, authorization)
+	return attempt, nil
+}
+
+func newCnonce() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// authorization builds the Authorization header value for a request
+func (c *digestChallenge) authorization(username, password, method, uri string, body []byte, cnonce string) (string, error) {
+	newHash, sess, err := digestHash(c.algorithm)
+	if err != nil {
+		return "", err
+	}
+	h := func(s string) string {
+		hh := newHash()
+		hh.Write([]byte(s))
+		return hex.EncodeToString(hh.Sum(nil))
+	}
+	nc := fmt.Sprintf("%08x", c.nc)
+
+	ha1 := h(username + ":" + c.realm + ":" + password)
+	if sess {
+		ha1 = h(ha1 + ":" + c.nonce + ":" + cnonce)
+	}
+	ha2 := h(method + ":" + uri)
+	if c.qop == DIGEST_QOP_AUTH_INT {
+		ha2 = h(method + ":" + uri + ":" + h(string(body)))
+	}
+	var response string
+	if c.qop == "" {
+		response = h(ha1 + ":" + c.nonce + ":" + ha2)
+	} else {
+		response = h(strings.Join([]string{ha1, c.nonce, nc, cnonce, c.qop, ha2}, ":"))
+	}
+
+	parts := []string{
+		fmt.Sprintf("username=%q", username),
+		fmt.Sprintf("realm=%q", c.realm),
+		fmt.Sprintf("nonce=%q", c.nonce),
+		fmt.Sprintf("uri=%q", uri),
+	}
+	if c.algorithm != "" {
+		parts = append(parts, "algorithm="+c.algorithm)
+	}
+	parts = append(parts, fmt.Sprintf("response=%q", response))
+	if c.opaque != "" {
+		parts = append(parts, fmt.Sprintf("opaque=%q", c.opaque))
+	}
+	if c.qop != "" {
+		parts = append(parts, "qop="+c.qop, "nc="+nc, fmt.Sprintf("cnonce=%q", cnonce))
+	}
+	return "Digest " + strings.Join(parts, ", "), nil
+}
+
+func digestHash(algorithm string) (func() hash.Hash, bool, error) {
+	upper := strings.ToUpper(algorithm)
+	sess := strings.HasSuffix(upper, "-SESS")
+	switch strings.TrimSuffix(upper, "-SESS") {
+	case "", "MD5":
+		return md5.New, sess, nil
+	case "SHA-256":
+		return sha256.New, sess, nil
+	}
+	return nil, false, fmt.Errorf("unsupported digest algorithm '%s'", algorithm)
+}
+
+// parseDigestChallenge returns the first Digest challenge among WWW-Authenticate values
+func parseDigestChallenge(values []string) *digestChallenge {
+	for _, value := range values {
+		scheme, rest, _ := strings.Cut(strings.TrimSpace(value), " ")
+		if !strings.EqualFold(scheme, "Digest") {
+			continue
+		}
+		params := parseAuthParams(rest)
+		c := &digestChallenge{
+			realm:     params["realm"],
+			nonce:     params["nonce"],
+			opaque:    params["opaque"],
+			algorithm: params["algorithm"],
+			stale:     strings.EqualFold(params["stale"], "true"),
+		}
+		if qop, ok := params["qop"]; ok {
+			options := strings.Split(qop, ",")
+			for i := range options {
+				options[i] = strings.TrimSpace(options[i])
+			}
+			// prefer auth, which does not depend on the body
+			c.qop = options[0]
+			for _, o := range options {
+				if o == DIGEST_QOP_AUTH {
+					c.qop = o
+				}
+			}
+		}
+		return c
+	}
+	return nil
+}
+
+// parseAuthParams splits comma separated key=value pairs whose values may be quoted strings containing commas
+func parseAuthParams(s string) map[string]string {
+	params := make(map[string]string)
+	for {
+		s = strings.TrimLeft(s, " ,")
+		key, rest, ok := strings.Cut(s, "=")
+		if !ok {
+			return params
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		rest = strings.TrimLeft(rest, " ")
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			var sb strings.Builder
+			i := 1
+			for ; i < len(rest) && rest[i] != '"'; i++ {
+				if rest[i] == '\\' && i+1 < len(rest) {
+					i++
+				}
+				sb.WriteByte(rest[i])
+			}
+			value, s = sb.String(), rest[min(i+1, len(rest)):]
+		} else {
+			value, s, _ = strings.Cut(rest, ",")
+			value = strings.TrimSpace(value)
+		}
+		params[key] = value
+	}
+}
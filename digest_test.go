@@ -0,0 +1,128 @@
+package restapireceiver
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDigestChallenge_RFC2617Example(t *testing.T) {
+	c := parseDigestChallenge([]string{`Basic realm="x"`, `Digest realm="testrealm@host.com", qop="auth,auth-int", ` +
+		`nonce="dcd98b7102dd2f0e8b11d0f600bfb0c093", opaque="5ccc069c403ebaf9f0171e9517f40e41"`})
+	require.NotNil(t, c)
+	assert.Equal(t, DIGEST_QOP_AUTH, c.qop)
+	c.nc = 1
+
+	authorization, err := c.authorization("Mufasa", "Circle Of Life", http.MethodGet, "/dir/index.html", nil, "0a4f113b")
+	require.NoError(t, err)
+	assert.Equal(t, `Digest username="Mufasa", realm="testrealm@host.com", nonce="dcd98b7102dd2f0e8b11d0f600bfb0c093", `+
+		`uri="/dir/index.html", response="6629fae49393a05397450978507c4ef1", opaque="5ccc069c403ebaf9f0171e9517f40e41", `+
+		`qop=auth, nc=00000001, cnonce="0a4f113b"`, authorization)
+}
+
+func TestParseAuthParams(t *testing.T) {
+	assert.Equal(t, map[string]string{"realm": `a, "b"`, "qop": "auth", "stale": "TRUE", "algorithm": "SHA-256"},
+		parseAuthParams(`realm="a, \"b\"", qop=auth ,stale=TRUE,algorithm=SHA-256`))
+}
+
+// digestServer is a stub server accepting the user "admin" with password "secret"
+type digestServer struct {
+	t          *testing.T
+	nonce      int
+	stale      bool
+	challenges int
+	counts     []int
+}
+
+func (s *digestServer) challenge(w http.ResponseWriter) {
+	s.challenges++
+	stale := ""
+	if s.stale {
+		stale = ", stale=true"
+	}
+	w.Header().Set(HEADER_KEY_WWW_AUTHENTICATE, fmt.Sprintf(`Digest realm="bmc", qop="auth", algorithm=SHA-256, nonce="n%d", opaque="o"%s`, s.nonce, stale))
+	w.WriteHeader(http.StatusUnauthorized)
+}
+
+func (s *digestServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	scheme, rest, _ := strings.Cut(r.Header.Get(HEADER_KEY_AUTHORIZATION), " ")
+	if scheme != "Digest" {
+		s.challenge(w)
+		return
+	}
+	params := parseAuthParams(rest)
+	if params["nonce"] != fmt.Sprintf("n%d", s.nonce) {
+		s.challenge(w)
+		return
+	}
+	nc, _ := strconv.ParseInt(params["nc"], 16, 64)
+	expected := &digestChallenge{realm: "bmc", nonce: params["nonce"], opaque: "o", algorithm: "SHA-256", qop: DIGEST_QOP_AUTH, nc: int(nc)}
+	authorization, err := expected.authorization("admin", "secret", r.Method, params["uri"], nil, params["cnonce"])
+	require.NoError(s.t, err)
+	if parseAuthParams(strings.TrimPrefix(authorization, "Digest "))["response"] != params["response"] {
+		s.stale = false
+		s.challenge(w)
+		return
+	}
+	s.counts = append(s.counts, int(nc))
+	w.Write([]byte(`{"name": "bmc1", "power": 180}`))
+}
+
+func TestScraper_ScrapeDigestAuth(t *testing.T) {
+	stub := &digestServer{t: t}
+	server := httptest.NewServer(stub)
+	defer server.Close()
+
+	cfg := &Config{Endpoint: server.URL, Username: "admin", Password: "secret", Auth: AuthConfig{Digest: true},
+		Description: Description{Endpoints: []EndpointDescription{{
+			Path: "/redfish/v1/Chassis/1/Power",
+			Resources: []ResourceDescription{{
+				Attributes: map[string]string{"name": "name"},
+				Metrics:    []MetricDescription{{Name: "power", Field: "power"}},
+			}},
+		}}}}
+	require.NoError(t, cfg.Validate())
+	s := newTestScraper(t, cfg)
+	for i := 0; i < 3; i++ {
+		metrics, err := s.scrape(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 1, metrics.MetricCount())
+	}
+	assert.Equal(t, 1, stub.challenges, "the challenge is reused")
+	assert.Equal(t, []int{1, 2, 3}, stub.counts)
+
+	// the server rotates its nonce
+	stub.nonce, stub.stale = 1, true
+	_, err := s.scrape(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, stub.challenges)
+	assert.Equal(t, []int{1, 2, 3, 1}, stub.counts)
+}
+
+func TestDigestTransport_WrongPassword(t *testing.T) {
+	stub := &digestServer{t: t}
+	server := httptest.NewServer(stub)
+	defer server.Close()
+
+	transport := newDigestTransport(http.DefaultTransport)
+	transport.setCredentials("admin", "wrong")
+	helper := NewHttpClientHelper()
+	helper.Client = &http.Client{Transport: transport}
+	req, _ := helper.NewGetRequest(server.URL)
+	_, err := helper.ExecuteJsonRequest(req)
+	assert.EqualError(t, err, "unexpected status code 401")
+	assert.Equal(t, 2, stub.challenges)
+
+	// the same nonce is rejected again without another round trip
+	req, _ = helper.NewGetRequest(server.URL)
+	_, err = helper.ExecuteJsonRequest(req)
+	assert.EqualError(t, err, "unexpected status code 401")
+	assert.Equal(t, 3, stub.challenges)
+}
@@ -0,0 +1,392 @@
+package restapireceiver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/config/configopaque"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/receiver"
+	"go.opentelemetry.io/collector/receiver/scrapererror"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+// resource attribute identifying discovered targets that have an ID
+const TARGET_ID_ATTRIBUTE = "restapi.target.id"
+
+// DiscoveryConfig scrapes targets discovered at runtime instead of the single configured endpoint.
+// Targets may reference Credentials by name; the others use the receiver's credentials.
+type DiscoveryConfig struct {
+	File        *FileDiscoveryConfig         `mapstructure:"file"`
+	HTTP        *HTTPDiscoveryConfig         `mapstructure:"http"`
+	Credentials map[string]CredentialsConfig `mapstructure:"credentials"`
+}
+
+// FileDiscoveryConfig reads targets from a JSON or YAML file in the format of Prometheus' file_sd,
+// read again whenever it changes
+type FileDiscoveryConfig struct {
+	Path string `mapstructure:"path"`
+}
+
+// CredentialsConfig holds credentials of discovered targets, with the meaning of the receiver's options of the same names
+type CredentialsConfig struct {
+	AuthToken     configopaque.String `mapstructure:"auth_token"`
+	AuthTokenFile string              `mapstructure:"auth_token_file"`
+	Username      string              `mapstructure:"username"`
+	Password      configopaque.String `mapstructure:"password"`
+	PasswordFile  string              `mapstructure:"password_file"`
+}
+
+func (c *DiscoveryConfig) enabled() bool {
+	return c.File != nil || c.HTTP != nil
+}
+
+// skipsInvalid reports whether invalid targets are skipped rather than rejecting the whole list. Inventories
+// are maintained elsewhere, while target files are fixed by whoever edits them.
+func (c *DiscoveryConfig) skipsInvalid() bool {
+	return c.HTTP != nil
+}
+
+// allowEmpty reports whether a list of groups without valid targets replaces the current targets. Target files
+// are emptied on purpose, while an inventory without valid targets is more likely an API or mapping problem.
+func (c *DiscoveryConfig) allowEmpty(groups int) bool {
+	return c.HTTP == nil || c.HTTP.AllowEmpty && groups == 0
+}
+
+func (c *DiscoveryConfig) validate() []string {
+	var errs []string
+	if c.File != nil && c.HTTP != nil {
+		errs = append(errs, "only one of 'discovery.file' or 'discovery.http' may be set")
+	}
+	if c.File != nil && c.File.Path == "" {
+		errs = append(errs, "'discovery.file.path' is required")
+	}
+	if c.HTTP != nil {
+		errs = append(errs, c.HTTP.validate(c.Credentials)...)
+	}
+	for _, name := range sortedKeys(c.Credentials) {
+		errs = append(errs, c.Credentials[name].validate(name)...)
+	}
+	return errs
+}
+
+func (c CredentialsConfig) validate(name string) []string {
+	var errs []string
+	if c.AuthToken != "" && c.AuthTokenFile != "" {
+		errs = append(errs, fmt.Sprintf("discovery.credentials.%s: only one of 'auth_token' or 'auth_token_file' may be set", name))
+	}
+	if c.Password != "" && c.PasswordFile != "" {
+		errs = append(errs, fmt.Sprintf("discovery.credentials.%s: only one of 'password' or 'password_file' may be set", name))
+	}
+	return errs
+}
+
+// apply replaces the credentials of the config
+func (c CredentialsConfig) apply(cfg *Config) {
+	cfg.AuthToken, cfg.AuthTokenFile = c.AuthToken, c.AuthTokenFile
+	cfg.Username, cfg.Password, cfg.PasswordFile = c.Username, c.Password, c.PasswordFile
+}
+
+// forEndpoint derives the config of a discovered endpoint from the receiver's config, with the named credentials if any
+func (c *Config) forEndpoint(endpoint, credentials string) *Config {
+	cfg := *c
+	cfg.Endpoint = endpoint
+	cfg.Discovery = DiscoveryConfig{}
+	if credentials != "" {
+		c.Discovery.Credentials[credentials].apply(&cfg)
+	}
+	return &cfg
+}
+
+// targetGroup is an entry of a target file: endpoints sharing labels and credentials.
+// ID identifies the target of a group with a single target across changes of its endpoint.
+type targetGroup struct {
+	Targets     []string          `yaml:"targets" json:"targets"`
+	Labels      map[string]string `yaml:"labels,omitempty" json:"labels,omitempty"`
+	Credentials string            `yaml:"credentials,omitempty" json:"credentials,omitempty"`
+	ID          string            `yaml:"id,omitempty" json:"id,omitempty"`
+}
+
+// parseTargetGroups parses target groups from JSON or YAML, YAML being a superset of JSON
+func parseTargetGroups(data []byte) ([]targetGroup, error) {
+	var groups []targetGroup
+	if err := yaml.Unmarshal(data, &groups); err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
+
+// targetSource provides the target groups to scrape
+type targetSource interface {
+	start(ctx context.Context, host component.Host) error
+	// groups returns the current target groups, and whether they may have changed since the last call
+	groups(ctx context.Context) ([]targetGroup, bool, error)
+	// applied is called with the groups the targets were updated from
+	applied(groups []targetGroup)
+	shutdown(ctx context.Context) error
+}
+
+// fileTargetSource reads target groups from a file, again whenever its modification time or size changes
+type fileTargetSource struct {
+	path    string
+	modTime time.Time
+	size    int64
+}
+
+func newFileTargetSource(cfg *FileDiscoveryConfig) *fileTargetSource {
+	return &fileTargetSource{path: cfg.Path}
+}
+
+func (f *fileTargetSource) start(_ context.Context, _ component.Host) error {
+	return nil
+}
+
+func (f *fileTargetSource) shutdown(_ context.Context) error {
+	return nil
+}
+
+func (f *fileTargetSource) applied(_ []targetGroup) {}
+
+func (f *fileTargetSource) groups(_ context.Context) ([]targetGroup, bool, error) {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return nil, false, err
+	}
+	if info.ModTime().Equal(f.modTime) && info.Size() == f.size {
+		return nil, false, nil
+	}
+	// a broken file is reported once, not at every scrape
+	f.modTime, f.size = info.ModTime(), info.Size()
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return nil, false, err
+	}
+	groups, err := parseTargetGroups(data)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to parse target file '%s': %w", f.path, err)
+	}
+	return groups, true, nil
+}
+
+// discoveredTarget is a discovered endpoint, scraped with a scraper of its own
+type discoveredTarget struct {
+	id          string
+	endpoint    string
+	labels      map[string]string
+	credentials string
+	scraper     *restapiScraper
+}
+
+// sameAs reports whether the target is scraped like the other one
+func (t *discoveredTarget) sameAs(other *discoveredTarget) bool {
+	return t.id == other.id && t.endpoint == other.endpoint && t.credentials == other.credentials && reflect.DeepEqual(t.labels, other.labels)
+}
+
+// key identifies the target among the discovered ones
+func (t *discoveredTarget) key() string {
+	if t.id != "" {
+		return t.id
+	}
+	return t.endpoint
+}
+
+// label adds the ID and labels of the target to the resources scraped from it, which already carry its
+// endpoint, keeping their own attributes
+func (t *discoveredTarget) label(metrics pmetric.Metrics) {
+	for i := 0; i < metrics.ResourceMetrics().Len(); i++ {
+		attrs := metrics.ResourceMetrics().At(i).Resource().Attributes()
+		if t.id != "" {
+			attrs.PutStr(TARGET_ID_ATTRIBUTE, t.id)
+		}
+		for k, v := range t.labels {
+			if _, ok := attrs.Get(k); !ok {
+				attrs.PutStr(k, v)
+			}
+		}
+	}
+}
+
+// discoveryScraper scrapes the targets of a target source, adding and removing them between scrapes
+type discoveryScraper struct {
+	logger   *zap.Logger
+	cfg      *Config
+	settings receiver.CreateSettings
+	source   targetSource
+	host     component.Host
+	targets  map[string]*discoveredTarget
+}
+
+func newDiscoveryScraper(logger *zap.Logger, cfg *Config, settings receiver.CreateSettings) *discoveryScraper {
+	d := &discoveryScraper{
+		logger:   logger,
+		cfg:      cfg,
+		settings: settings,
+		targets:  make(map[string]*discoveredTarget),
+	}
+	if cfg.Discovery.HTTP != nil {
+		d.source = newHTTPTargetSource(logger, cfg, settings)
+	} else {
+		d.source = newFileTargetSource(cfg.Discovery.File)
+	}
+	return d
+}
+
+// start discovers the initial targets; failing to read them is fatal, later failures keep the previous targets
+func (d *discoveryScraper) start(ctx context.Context, host component.Host) error {
+	d.host = host
+	if err := d.source.start(ctx, host); err != nil {
+		return err
+	}
+	return d.refresh(ctx)
+}
+
+// refresh reads the target groups, and starts the added targets and drops the removed ones if they changed
+func (d *discoveryScraper) refresh(ctx context.Context) error {
+	groups, changed, err := d.source.groups(ctx)
+	if err != nil || !changed {
+		return err
+	}
+	targets, invalid := d.resolve(groups)
+	if len(invalid) > 0 {
+		if !d.cfg.Discovery.skipsInvalid() {
+			return errors.New(strings.Join(invalid, ", "))
+		}
+		d.logger.Warn("skipped invalid targets", zap.Strings("reasons", invalid))
+	}
+	// a list without valid targets is not applied over the current targets, unless it is empty on purpose
+	unexpectedlyEmpty := len(targets) == 0 && !d.cfg.Discovery.allowEmpty(len(groups))
+	if unexpectedlyEmpty && len(d.targets) > 0 {
+		return errors.New("no valid targets discovered")
+	}
+	var added, removed int
+	for key, t := range targets {
+		if previous, ok := d.targets[key]; ok && previous.sameAs(t) {
+			targets[key] = previous
+			continue
+		}
+		added++
+		if err := d.startTarget(ctx, t); err != nil {
+			d.logger.Warn("failed to start target", zap.String("target", key), zap.Error(err))
+		}
+	}
+	for key, t := range d.targets {
+		if current, ok := targets[key]; !ok || current != t {
+			removed++
+			t.stop()
+		}
+	}
+	d.targets = targets
+	if added > 0 || removed > 0 {
+		d.logger.Info("discovered targets", zap.Int("targets", len(targets)), zap.Int("added", added), zap.Int("removed", removed))
+	}
+	if !unexpectedlyEmpty {
+		d.source.applied(groups)
+	}
+	return nil
+}
+
+// resolve validates target groups into targets by key, returning why the invalid groups and targets were left out
+func (d *discoveryScraper) resolve(groups []targetGroup) (map[string]*discoveredTarget, []string) {
+	targets := make(map[string]*discoveredTarget)
+	var invalid []string
+	for i, g := range groups {
+		if _, ok := d.cfg.Discovery.Credentials[g.Credentials]; g.Credentials != "" && !ok {
+			invalid = append(invalid, fmt.Sprintf("groups[%d]: unknown credentials '%s'", i, g.Credentials))
+			continue
+		}
+		if g.ID != "" && len(g.Targets) != 1 {
+			invalid = append(invalid, fmt.Sprintf("groups[%d]: 'id' requires a single target", i))
+			continue
+		}
+		// targets without a scheme are requested over http by BuildUrl, like the receiver's endpoint
+		for _, endpoint := range g.Targets {
+			if e := validateEndpoint(endpoint); len(e) > 0 {
+				invalid = append(invalid, fmt.Sprintf("groups[%d]: target '%s': %s", i, endpoint, strings.Join(e, ", ")))
+				continue
+			}
+			t := &discoveredTarget{id: g.ID, endpoint: endpoint, labels: g.Labels, credentials: g.Credentials}
+			if _, ok := targets[t.key()]; ok {
+				invalid = append(invalid, fmt.Sprintf("groups[%d]: duplicate target '%s'", i, t.key()))
+				continue
+			}
+			targets[t.key()] = t
+		}
+	}
+	return targets, invalid
+}
+
+// startTarget creates and starts the scraper of a target, with the receiver's config for its endpoint and credentials
+func (d *discoveryScraper) startTarget(ctx context.Context, t *discoveredTarget) error {
+	scraper := newScraper(d.logger.With(zap.String("endpoint", t.endpoint)), d.cfg.forEndpoint(t.endpoint, t.credentials), d.settings)
+	if err := scraper.start(ctx, d.host); err != nil {
+		return err
+	}
+	t.scraper = scraper
+	return nil
+}
+
+func (t *discoveredTarget) stop() {
+	if t.scraper != nil {
+		t.scraper.shutdown(context.Background())
+	}
+}
+
+// shutdown stops the scrapers of all targets and the target source
+func (d *discoveryScraper) shutdown(ctx context.Context) error {
+	for _, t := range d.targets {
+		t.stop()
+	}
+	return d.source.shutdown(ctx)
+}
+
+// scrape scrapes all targets concurrently, restarting those that failed to start
+func (d *discoveryScraper) scrape(ctx context.Context) (pmetric.Metrics, error) {
+	if err := d.refresh(ctx); err != nil {
+		d.logger.Warn("failed to discover targets, keeping the previous ones", zap.Error(err))
+	}
+
+	keys := sortedKeys(d.targets)
+	results := make([]pmetric.Metrics, len(keys))
+	errs := make([]error, len(keys))
+	var wg sync.WaitGroup
+	for i, key := range keys {
+		t := d.targets[key]
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if t.scraper == nil {
+				if errs[i] = d.startTarget(ctx, t); errs[i] != nil {
+					return
+				}
+			}
+			results[i], errs[i] = t.scraper.scrape(ctx)
+			t.label(results[i])
+		}(i)
+	}
+	wg.Wait()
+
+	metrics := pmetric.NewMetrics()
+	var scrapeErrs scrapererror.ScrapeErrors
+	for i, key := range keys {
+		if errs[i] != nil {
+			failed := 1
+			var partial scrapererror.PartialScrapeError
+			if errors.As(errs[i], &partial) {
+				failed = partial.Failed
+			}
+			scrapeErrs.AddPartial(failed, fmt.Errorf("target '%s': %w", key, errs[i]))
+		}
+		if results[i] != (pmetric.Metrics{}) {
+			results[i].ResourceMetrics().MoveAndAppendTo(metrics.ResourceMetrics())
+		}
+	}
+	return metrics, scrapeErrs.Combine()
+}
@@ -0,0 +1,128 @@
+package restapireceiver
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/receiver/receivertest"
+	"go.opentelemetry.io/collector/receiver/scrapererror"
+	"go.uber.org/zap"
+)
+
+func newClusterServer(t *testing.T, token string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(HEADER_KEY_AUTHORIZATION) != token {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(testClusterResponse))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// resourcesByEndpoint returns the attributes of the scraped resources by the endpoint of their target
+func resourcesByEndpoint(metrics pmetric.Metrics) map[string][]map[string]any {
+	resources := make(map[string][]map[string]any)
+	for i := 0; i < metrics.ResourceMetrics().Len(); i++ {
+		attrs := metrics.ResourceMetrics().At(i).Resource().Attributes().AsRaw()
+		endpoint := attrs[REQUEST_METRICS_ENDPOINT_ATTRIBUTE].(string)
+		resources[endpoint] = append(resources[endpoint], attrs)
+	}
+	return resources
+}
+
+func TestDiscoveryScraper_FileTargets(t *testing.T) {
+	array1, array2, array3 := newClusterServer(t, "default"), newClusterServer(t, "t0k3n"), newClusterServer(t, "default")
+	path := filepath.Join(t.TempDir(), "targets.yaml")
+	t0 := time.Now().Add(-time.Hour)
+	writeSecret(t, path, fmt.Sprintf(`
+- targets: [%s]
+  labels: {site: ams, cluster_name: ignored}
+- targets: [%s]
+  labels: {site: fra}
+  credentials: arrays
+`, array1.URL, array2.URL), t0)
+
+	cfg := &Config{AuthToken: "default", Description: testClusterDescription, Discovery: DiscoveryConfig{
+		File:        &FileDiscoveryConfig{Path: path},
+		Credentials: map[string]CredentialsConfig{"arrays": {AuthToken: "t0k3n"}},
+	}}
+	require.NoError(t, cfg.Validate())
+	d := newDiscoveryScraper(zap.NewNop(), cfg, receivertest.NewNopCreateSettings())
+	require.NoError(t, d.start(context.Background(), componenttest.NewNopHost()))
+	defer d.shutdown(context.Background())
+
+	metrics, err := d.scrape(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 18, metrics.MetricCount())
+	resources := resourcesByEndpoint(metrics)
+	require.Len(t, resources[array1.URL], 3)
+	require.Len(t, resources[array2.URL], 3)
+	assert.Equal(t, "ams", resources[array1.URL][0]["site"])
+	assert.Equal(t, "cluster1", resources[array1.URL][0]["cluster_name"], "labels do not override scraped attributes")
+	assert.Equal(t, "fra", resources[array2.URL][0]["site"])
+	kept := d.targets[array1.URL]
+
+	// array2 is removed and array3 added, as JSON and without a scheme
+	array3Host := strings.TrimPrefix(array3.URL, "http://")
+	writeSecret(t, path, fmt.Sprintf(`[{"targets": [%q, %q], "labels": {"site": "ams", "cluster_name": "ignored"}}]`, array1.URL, array3Host), t0.Add(time.Minute))
+	metrics, err = d.scrape(context.Background())
+	require.NoError(t, err)
+	resources = resourcesByEndpoint(metrics)
+	assert.Len(t, resources, 2)
+	assert.Len(t, resources[array3Host], 3, "scraped over http")
+	assert.Same(t, kept, d.targets[array1.URL], "unchanged targets keep their scraper")
+
+	// a broken file keeps the previous targets
+	writeSecret(t, path, `[{"targets": [`, t0.Add(2*time.Minute))
+	metrics, err = d.scrape(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, resourcesByEndpoint(metrics), 2)
+}
+
+func TestDiscoveryScraper_Errors(t *testing.T) {
+	array := newClusterServer(t, "default")
+	path := filepath.Join(t.TempDir(), "targets.yaml")
+	cfg := &Config{AuthToken: "default", Description: testClusterDescription, Discovery: DiscoveryConfig{File: &FileDiscoveryConfig{Path: path}}}
+	d := newDiscoveryScraper(zap.NewNop(), cfg, receivertest.NewNopCreateSettings())
+	assert.ErrorContains(t, d.start(context.Background(), componenttest.NewNopHost()), "no such file")
+
+	writeSecret(t, path, `[{targets: ["unix://api.sock", "array01:8443", "array01:8443"], credentials: vault}, {targets: ["x"], credentials: vault}]`, time.Now())
+	assert.EqualError(t, d.start(context.Background(), componenttest.NewNopHost()),
+		"groups[0]: unknown credentials 'vault', groups[1]: unknown credentials 'vault'")
+
+	cfg.Discovery.Credentials = map[string]CredentialsConfig{"vault": {}}
+	d = newDiscoveryScraper(zap.NewNop(), cfg, receivertest.NewNopCreateSettings())
+	assert.EqualError(t, d.start(context.Background(), componenttest.NewNopHost()),
+		"groups[0]: target 'unix://api.sock': 'endpoint' must be an absolute socket path like unix:///var/run/api.sock, "+
+			"groups[0]: duplicate target 'array01:8443'")
+
+	// a failing target does not fail the others
+	writeSecret(t, path, fmt.Sprintf(`[{targets: [%q, "http://127.0.0.1:1"]}]`, array.URL), time.Now().Add(time.Minute))
+	require.NoError(t, d.start(context.Background(), componenttest.NewNopHost()))
+	metrics, err := d.scrape(context.Background())
+	require.Error(t, err)
+	assert.True(t, scrapererror.IsPartialScrapeError(err))
+	assert.ErrorContains(t, err, "target 'http://127.0.0.1:1'")
+	assert.Equal(t, 9, metrics.MetricCount())
+}
+
+func TestDiscoveryConfig_Validate(t *testing.T) {
+	cfg := &Config{Endpoint: "http://example.com", Discovery: DiscoveryConfig{
+		File:        &FileDiscoveryConfig{},
+		Credentials: map[string]CredentialsConfig{"arrays": {AuthToken: "t", AuthTokenFile: "/run/secrets/token"}},
+	}}
+	assert.EqualError(t, cfg.Validate(), "Config validation failed: 'endpoint' cannot be combined with 'discovery', "+
+		"'discovery.file.path' is required, discovery.credentials.arrays: only one of 'auth_token' or 'auth_token_file' may be set")
+}
@@ -0,0 +1,44 @@
+package restapireceiver
+
+import (
+	"net"
+	neturl "net/url"
+	"strings"
+	"text/template"
+)
+
+// endpointTemplateData is what 'endpoint_template' is rendered with: the configured endpoint, such as the
+// host:port receiver_creator sets for an observed endpoint, and its host and port
+type endpointTemplateData struct {
+	Endpoint string
+	Host     string
+	Port     string
+}
+
+func newEndpointTemplateData(endpoint string) *endpointTemplateData {
+	data := &endpointTemplateData{Endpoint: endpoint, Host: endpoint}
+	if strings.Contains(endpoint, "://") {
+		if u, err := neturl.Parse(endpoint); err == nil {
+			data.Host, data.Port = u.Hostname(), u.Port()
+		}
+	} else if host, port, err := net.SplitHostPort(endpoint); err == nil {
+		data.Host, data.Port = host, port
+	}
+	return data
+}
+
+// resolveEndpoint returns the endpoint to scrape: the configured one, or the endpoint template rendered with it
+func (c *Config) resolveEndpoint() (string, error) {
+	if c.EndpointTemplate == "" {
+		return c.Endpoint, nil
+	}
+	t, err := template.New("endpoint_template").Option("missingkey=error").Parse(c.EndpointTemplate)
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	if err := t.Execute(&sb, newEndpointTemplateData(c.Endpoint)); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
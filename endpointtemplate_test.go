@@ -0,0 +1,40 @@
+package restapireceiver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_ResolveEndpoint(t *testing.T) {
+	tests := []struct {
+		endpoint, template, expected string
+	}{
+		{"10.0.0.5:8080", "", "10.0.0.5:8080"},
+		{"10.0.0.5:8080", "https://{{ .Host }}:{{ .Port }}/api/v1", "https://10.0.0.5:8080/api/v1"},
+		{"[fd00::5]:443", "https://[{{ .Host }}]/api", "https://[fd00::5]/api"},
+		{"http://array01:8080/base", "https://{{ .Host }}{{ if .Port }}:{{ .Port }}{{ end }}", "https://array01:8080"},
+		{"array01", "{{ .Endpoint }}/api", "array01/api"},
+	}
+	for _, tt := range tests {
+		cfg := &Config{Endpoint: tt.endpoint, EndpointTemplate: tt.template}
+		endpoint, err := cfg.resolveEndpoint()
+		require.NoError(t, err)
+		assert.Equal(t, tt.expected, endpoint)
+	}
+}
+
+func TestConfig_EndpointTemplateErrors(t *testing.T) {
+	cfg := &Config{Endpoint: "10.0.0.5:8080", EndpointTemplate: "https://{{ .Hostname }}", AuthToken: "t0k3n"}
+	assert.ErrorContains(t, cfg.Validate(), "'endpoint_template': template: endpoint_template:1:11: executing")
+
+	cfg.EndpointTemplate = "https://{{ .Host"
+	assert.ErrorContains(t, cfg.Validate(), "'endpoint_template': template: endpoint_template:1: unclosed action")
+
+	cfg.EndpointTemplate = "unix://{{ .Host }}.sock"
+	assert.EqualError(t, cfg.Validate(), "Config validation failed: 'endpoint' must be an absolute socket path like unix:///var/run/api.sock")
+
+	cfg = &Config{EndpointTemplate: "https://{{ .Host }}", Discovery: DiscoveryConfig{File: &FileDiscoveryConfig{Path: "targets.yaml"}}}
+	assert.EqualError(t, cfg.Validate(), "Config validation failed: 'endpoint_template' cannot be combined with 'discovery'")
+}
@@ -0,0 +1,397 @@
+package restapireceiver
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// expression is a compiled arithmetic expression used by derived metrics, e.g.
+// `used / total * 100` or `sum(nodes[*].capacity)`.
+//
+// Identifiers first resolve to metrics already extracted for the same resource item,
+// otherwise they are treated as selectors relative to the item (or the root with `$.`).
+// Supported operators are + - * / % and parentheses; supported functions are
+// sum, avg, min, max, count and abs.
+type expression struct {
+	source string
+	root   exprNode
+}
+
+// exprContext holds what an expression can reference during evaluation
+type exprContext struct {
+	root any
+	item any
+	vars map[string]float64
+}
+
+type exprNode interface {
+	eval(ctx *exprContext) (float64, error)
+}
+
+func compileExpression(source string) (*expression, error) {
+	p := &exprParser{input: source}
+	if err := p.tokenize(); err != nil {
+		return nil, fmt.Errorf("expression '%s': %w", source, err)
+	}
+	node, err := p.parseExpr()
+	if err == nil && p.pos < len(p.tokens) {
+		err = fmt.Errorf("unexpected '%s'", p.tokens[p.pos].text)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("expression '%s': %w", source, err)
+	}
+	return &expression{source: source, root: node}, nil
+}
+
+func (e *expression) Eval(ctx *exprContext) (float64, error) {
+	v, err := e.root.eval(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("expression '%s': %w", e.source, err)
+	}
+	return v, nil
+}
+
+type numberNode struct {
+	value float64
+}
+
+func (n *numberNode) eval(_ *exprContext) (float64, error) {
+	return n.value, nil
+}
+
+type unaryNode struct {
+	operand exprNode
+}
+
+func (n *unaryNode) eval(ctx *exprContext) (float64, error) {
+	v, err := n.operand.eval(ctx)
+	return -v, err
+}
+
+type binaryNode struct {
+	op          byte
+	left, right exprNode
+}
+
+func (n *binaryNode) eval(ctx *exprContext) (float64, error) {
+	l, err := n.left.eval(ctx)
+	if err != nil {
+		return 0, err
+	}
+	r, err := n.right.eval(ctx)
+	if err != nil {
+		return 0, err
+	}
+	switch n.op {
+	case '+':
+		return l + r, nil
+	case '-':
+		return l - r, nil
+	case '*':
+		return l * r, nil
+	case '/':
+		if r == 0 {
+			return 0, fmt.Errorf("division by zero")
+		}
+		return l / r, nil
+	case '%':
+		if r == 0 {
+			return 0, fmt.Errorf("division by zero")
+		}
+		return math.Mod(l, r), nil
+	}
+	return 0, fmt.Errorf("unknown operator '%c'", n.op)
+}
+
+type refNode struct {
+	name string
+	sel  selector
+}
+
+func (n *refNode) eval(ctx *exprContext) (float64, error) {
+	values, err := n.values(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if len(values) != 1 {
+		return 0, fmt.Errorf("'%s' matched %d values, expected 1", n.name, len(values))
+	}
+	return values[0], nil
+}
+
+func (n *refNode) values(ctx *exprContext) ([]float64, error) {
+	if v, ok := ctx.vars[n.name]; ok {
+		return []float64{v}, nil
+	}
+	raw := n.sel.Select(ctx.root, ctx.item)
+	values := make([]float64, 0, len(raw))
+	for _, r := range raw {
+		f, err := toFloat(r)
+		if err != nil {
+			return nil, fmt.Errorf("'%s': %w", n.name, err)
+		}
+		values = append(values, f)
+	}
+	return values, nil
+}
+
+type callNode struct {
+	fn   string
+	args []exprNode
+}
+
+var exprFunctions = map[string]func([]float64) (float64, error){
+	"sum": func(v []float64) (float64, error) {
+		total := 0.0
+		for _, x := range v {
+			total += x
+		}
+		return total, nil
+	},
+	"avg": func(v []float64) (float64, error) {
+		if len(v) == 0 {
+			return 0, fmt.Errorf("avg of no values")
+		}
+		total := 0.0
+		for _, x := range v {
+			total += x
+		}
+		return total / float64(len(v)), nil
+	},
+	"min": func(v []float64) (float64, error) {
+		if len(v) == 0 {
+			return 0, fmt.Errorf("min of no values")
+		}
+		m := v[0]
+		for _, x := range v[1:] {
+			m = math.Min(m, x)
+		}
+		return m, nil
+	},
+	"max": func(v []float64) (float64, error) {
+		if len(v) == 0 {
+			return 0, fmt.Errorf("max of no values")
+		}
+		m := v[0]
+		for _, x := range v[1:] {
+			m = math.Max(m, x)
+		}
+		return m, nil
+	},
+	"count": func(v []float64) (float64, error) {
+		return float64(len(v)), nil
+	},
+	"abs": func(v []float64) (float64, error) {
+		if len(v) != 1 {
+			return 0, fmt.Errorf("abs takes exactly one value")
+		}
+		return math.Abs(v[0]), nil
+	},
+}
+
+func (n *callNode) eval(ctx *exprContext) (float64, error) {
+	values := []float64{}
+	for _, arg := range n.args {
+		// selectors passed to functions contribute every matched value
+		if ref, ok := arg.(*refNode); ok {
+			if _, isVar := ctx.vars[ref.name]; n.fn == "count" && !isVar {
+				// count does not need numbers, so objects and strings can be counted too
+				for range ref.sel.Select(ctx.root, ctx.item) {
+					values = append(values, 0)
+				}
+				continue
+			}
+			vs, err := ref.values(ctx)
+			if err != nil {
+				return 0, err
+			}
+			values = append(values, vs...)
+			continue
+		}
+		v, err := arg.eval(ctx)
+		if err != nil {
+			return 0, err
+		}
+		values = append(values, v)
+	}
+	return exprFunctions[n.fn](values)
+}
+
+type exprToken struct {
+	kind byte // 'n' number, 'i' identifier, or the operator/punctuation character itself
+	text string
+}
+
+type exprParser struct {
+	input  string
+	tokens []exprToken
+	pos    int
+}
+
+func (p *exprParser) tokenize() error {
+	in := p.input
+	for i := 0; i < len(in); {
+		c := rune(in[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case strings.ContainsRune("+-*/%(),", c):
+			p.tokens = append(p.tokens, exprToken{kind: byte(c), text: string(c)})
+			i++
+		case unicode.IsDigit(c) || c == '.':
+			j := i
+			for j < len(in) && (unicode.IsDigit(rune(in[j])) || in[j] == '.' || in[j] == 'e' || in[j] == 'E' ||
+				((in[j] == '+' || in[j] == '-') && (in[j-1] == 'e' || in[j-1] == 'E'))) {
+				j++
+			}
+			p.tokens = append(p.tokens, exprToken{kind: 'n', text: in[i:j]})
+			i = j
+		case unicode.IsLetter(c) || c == '_' || c == '$':
+			j := i
+			for j < len(in) {
+				d := rune(in[j])
+				if d == '[' {
+					end := strings.IndexByte(in[j:], ']')
+					if end < 0 {
+						return fmt.Errorf("unterminated '[' at position %d", j)
+					}
+					j += end + 1
+					continue
+				}
+				if !(unicode.IsLetter(d) || unicode.IsDigit(d) || d == '_' || d == '.' || d == '$') {
+					break
+				}
+				j++
+			}
+			p.tokens = append(p.tokens, exprToken{kind: 'i', text: in[i:j]})
+			i = j
+		default:
+			return fmt.Errorf("unexpected character '%c' at position %d", c, i)
+		}
+	}
+	return nil
+}
+
+func (p *exprParser) peek() byte {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos].kind
+	}
+	return 0
+}
+
+// parseExpr: term (('+'|'-') term)*
+func (p *exprParser) parseExpr() (exprNode, error) {
+	left, err := p.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == '+' || p.peek() == '-' {
+		op := p.tokens[p.pos].kind
+		p.pos++
+		right, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: op, left: left, right: right}
+	}
+	return left, nil
+}
+
+// parseTerm: unary (('*'|'/'|'%') unary)*
+func (p *exprParser) parseTerm() (exprNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == '*' || p.peek() == '/' || p.peek() == '%' {
+		op := p.tokens[p.pos].kind
+		p.pos++
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: op, left: left, right: right}
+	}
+	return left, nil
+}
+
+// parseUnary: '-' unary | primary
+func (p *exprParser) parseUnary() (exprNode, error) {
+	if p.peek() == '-' {
+		p.pos++
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryNode{operand: operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+// parsePrimary: number | '(' expr ')' | ident '(' args ')' | ident
+func (p *exprParser) parsePrimary() (exprNode, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+	tok := p.tokens[p.pos]
+	p.pos++
+	switch tok.kind {
+	case 'n':
+		v, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number '%s'", tok.text)
+		}
+		return &numberNode{value: v}, nil
+	case '(':
+		node, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ')' {
+			return nil, fmt.Errorf("missing ')'")
+		}
+		p.pos++
+		return node, nil
+	case 'i':
+		if p.peek() == '(' {
+			return p.parseCall(tok.text)
+		}
+		sel, err := parseSelector(tok.text)
+		if err != nil {
+			return nil, err
+		}
+		return &refNode{name: tok.text, sel: sel}, nil
+	}
+	return nil, fmt.Errorf("unexpected '%s'", tok.text)
+}
+
+func (p *exprParser) parseCall(name string) (exprNode, error) {
+	if _, ok := exprFunctions[name]; !ok {
+		return nil, fmt.Errorf("unknown function '%s'", name)
+	}
+	p.pos++ // '('
+	call := &callNode{fn: name}
+	if p.peek() == ')' {
+		p.pos++
+		return call, nil
+	}
+	for {
+		arg, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		call.args = append(call.args, arg)
+		if p.peek() == ',' {
+			p.pos++
+			continue
+		}
+		if p.peek() != ')' {
+			return nil, fmt.Errorf("missing ')' after arguments of '%s'", name)
+		}
+		p.pos++
+		return call, nil
+	}
+}
@@ -0,0 +1,67 @@
+package restapireceiver
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpression_Eval(t *testing.T) {
+	var doc any
+	err := json.Unmarshal([]byte(`{"nodes": [{"capacity": 30, "used": 10}, {"capacity": 10, "used": 5}], "total": 40}`), &doc)
+	assert.NoError(t, err)
+	vars := map[string]float64{"used": 15, "total": 60}
+
+	tests := []struct {
+		name     string
+		expr     string
+		expected float64
+		errMsg   string
+	}{
+		{name: "Precedence", expr: "1 + 2 * 3", expected: 7},
+		{name: "Parentheses", expr: "(1 + 2) * 3", expected: 9},
+		{name: "Unary", expr: "-2 + 5", expected: 3},
+		{name: "Percentage", expr: "used / total * 100", expected: 25},
+		{name: "VarsBeforeFields", expr: "total", expected: 60},
+		{name: "Sum", expr: "sum(nodes[*].capacity)", expected: 40},
+		{name: "Avg", expr: "avg(nodes[*].used)", expected: 7.5},
+		{name: "MinMax", expr: "max(nodes[*].used) - min(nodes[*].used)", expected: 5},
+		{name: "Count", expr: "count(nodes[*])", expected: 2},
+		{name: "RootSelector", expr: "$.nodes[0].capacity", expected: 30},
+		{name: "DivisionByZero", expr: "used / 0", errMsg: "expression 'used / 0': division by zero"},
+		{name: "MultipleValues", expr: "nodes[*].used", errMsg: "expression 'nodes[*].used': 'nodes[*].used' matched 2 values, expected 1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := compileExpression(tt.expr)
+			assert.NoError(t, err)
+			v, err := e.Eval(&exprContext{root: doc, item: doc, vars: vars})
+			if tt.errMsg != "" {
+				assert.EqualError(t, err, tt.errMsg)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expected, v)
+			}
+		})
+	}
+}
+
+func TestCompileExpression_Invalid(t *testing.T) {
+	tests := []struct {
+		expr   string
+		errMsg string
+	}{
+		{expr: "1 +", errMsg: "expression '1 +': unexpected end of expression"},
+		{expr: "(1 + 2", errMsg: "expression '(1 + 2': missing ')'"},
+		{expr: "median(a)", errMsg: "expression 'median(a)': unknown function 'median'"},
+		{expr: "a # b", errMsg: "expression 'a # b': unexpected character '#' at position 2"},
+		{expr: "a b", errMsg: "expression 'a b': unexpected 'b'"},
+	}
+
+	for _, tt := range tests {
+		_, err := compileExpression(tt.expr)
+		assert.EqualError(t, err, tt.errMsg)
+	}
+}
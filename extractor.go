@@ -0,0 +1,192 @@
+package restapireceiver
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+const (
+	SCOPE_NAME    = "otelcol/restapireceiver"
+	SCOPE_VERSION = "0.0.1"
+)
+
+// extraction holds the state of one scrape shared by all extracted metrics
+type extraction struct {
+	builder   *MetricsBuilder
+	endpoint  string    // target endpoint, which resources are stamped with after the scrape
+	now       time.Time // timestamp of datapoints without a described timestamp
+	startTime time.Time // start of cumulative sums without a described start time
+	series    *seriesCache
+}
+
+// extractMetrics maps a decoded response to metrics according to the endpoint description.
+// Problems with individual resources or metrics are returned without stopping the extraction.
+func (ce *compiledEndpoint) extractMetrics(root any, ex *extraction) []error {
+	var errs []error
+	for _, cr := range ce.resources {
+		for _, item := range cr.selector.Select(root, root) {
+			errs = append(errs, cr.extractItem(root, item, ex)...)
+		}
+	}
+	return errs
+}
+
+func (cr *compiledResource) extractItem(root, item any, ex *extraction) []error {
+	var errs []error
+	attrs := make(map[string]any)
+	for name, sel := range cr.attributes {
+		if v, ok := sel.SelectOne(root, item); ok && v != nil {
+			attrs[name] = v
+		}
+	}
+	rb, err := ex.builder.GetOrCreateResource(attrs, SCOPE_NAME, SCOPE_VERSION)
+	if err != nil {
+		return []error{err}
+	}
+
+	// field metrics first, so that expressions can refer to any of them
+	values := make(map[string]float64)
+	currentStates := make(map[string]string)
+	seriesPoints := make(map[string][]DataPoint)
+	for _, cm := range cr.metrics {
+		if cm.expr != nil {
+			continue
+		}
+		if cm.series != nil {
+			points, err := cm.series.samples(cm, root, item)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("metric '%s': %w", cm.Name, err))
+			}
+			if ex.series != nil {
+				// a series is identified like the resource it is emitted on, which includes the target endpoint
+				points = ex.series.filterNew(ex.endpoint+"/"+generateResourceKey(attrs)+"/"+cm.Name, points, ex.now)
+			}
+			if len(points) > 0 {
+				seriesPoints[cm.Name] = points
+			}
+			continue
+		}
+		raw, ok := cm.field.SelectOne(root, item)
+		if !ok {
+			errs = append(errs, fmt.Errorf("metric '%s': field '%s' not found", cm.Name, cm.Field))
+			continue
+		}
+		if cm.states != nil && cm.states.Mode == STATE_MODE_STATE_SET {
+			state, err := cm.states.currentState(raw)
+			if err == nil {
+				currentStates[cm.Name] = state
+			} else if err != errUnknownStateIgnored {
+				errs = append(errs, fmt.Errorf("metric '%s': %w", cm.Name, err))
+			}
+			continue
+		}
+		v, err := cm.fieldValue(raw)
+		if err == errUnknownStateIgnored {
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("metric '%s': %w", cm.Name, err))
+			continue
+		}
+		values[cm.Name] = v
+	}
+	for _, cm := range cr.metrics {
+		if cm.expr == nil {
+			continue
+		}
+		v, err := cm.expr.Eval(&exprContext{root: root, item: item, vars: values})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("metric '%s': %w", cm.Name, err))
+			continue
+		}
+		values[cm.Name] = v
+	}
+
+	for _, cm := range cr.metrics {
+		if points, ok := seriesPoints[cm.Name]; ok {
+			if cm.Type != METRIC_TYPE_SUM {
+				rb.AddGaugeMetricSeries(cm.Name, cm.Unit, points, cm.ValueType == VALUE_TYPE_INT)
+				continue
+			}
+			startTime, err := resolveTime(firstTimestamp(cm.startTime, cr.startTime), root, item, ex.startTime)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("metric '%s': %w", cm.Name, err))
+				continue
+			}
+			rb.AddSumMetricSeries(cm.Name, cm.Unit, points, cm.ValueType == VALUE_TYPE_INT, cm.Monotonic, startTime)
+			continue
+		}
+		state, isState := currentStates[cm.Name]
+		v, ok := values[cm.Name]
+		if !ok && !isState {
+			continue
+		}
+		timestamp, err := resolveTime(firstTimestamp(cm.timestamp, cr.timestamp), root, item, ex.now)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("metric '%s': %w", cm.Name, err))
+			continue
+		}
+		switch {
+		case isState:
+			rb.AddStateSetMetric(cm.Name, cm.Unit, cm.states.Attribute, cm.states.states, state, timestamp)
+		case cm.Type == METRIC_TYPE_SUM:
+			startTime, err := resolveTime(firstTimestamp(cm.startTime, cr.startTime), root, item, ex.startTime)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("metric '%s': %w", cm.Name, err))
+				continue
+			}
+			if cm.ValueType == VALUE_TYPE_INT {
+				rb.AddSumMetricInt(cm.Name, cm.Unit, int64(math.Round(v)), cm.Monotonic, startTime, timestamp)
+			} else {
+				rb.AddSumMetricDouble(cm.Name, cm.Unit, v, cm.Monotonic, startTime, timestamp)
+			}
+		case cm.ValueType == VALUE_TYPE_INT:
+			rb.AddGaugeMetricInt(cm.Name, cm.Unit, int64(math.Round(v)), timestamp)
+		default:
+			rb.AddGaugeMetricDouble(cm.Name, cm.Unit, v, timestamp)
+		}
+	}
+	return errs
+}
+
+// firstTimestamp returns the metric's own timestamp description, or the resource's one
+func firstTimestamp(metric, resource *compiledTimestamp) *compiledTimestamp {
+	if metric != nil {
+		return metric
+	}
+	return resource
+}
+
+// resolveTime reads a described time from the object, or returns the fallback when none is described
+func resolveTime(ct *compiledTimestamp, root, item any, fallback time.Time) (time.Time, error) {
+	if ct == nil {
+		return fallback, nil
+	}
+	return ct.resolve(root, item)
+}
+
+// fieldValue converts a raw field value to a number in the unit of the metric
+func (cm *compiledMetric) fieldValue(raw any) (float64, error) {
+	if cm.states != nil {
+		return cm.states.lookupValue(raw)
+	}
+	unit := cm.SourceUnit
+	var v float64
+	var err error
+	if str, ok := raw.(string); ok {
+		var parsedUnit string
+		if v, parsedUnit, err = parseQuantity(str); err != nil {
+			return 0, err
+		}
+		if parsedUnit != "" {
+			unit = parsedUnit
+		}
+	} else if v, err = toFloat(raw); err != nil {
+		return 0, err
+	}
+	if unit == "" || cm.Unit == "" {
+		return v, nil
+	}
+	return convertUnit(v, unit, cm.Unit)
+}
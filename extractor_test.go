@@ -0,0 +1,298 @@
+package restapireceiver
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+)
+
+const testClusterResponse = `{
+	"cluster": {"name": "cluster1"},
+	"nodes": [
+		{"name": "node1", "ip": "10.10.1.20", "capacity": 2048, "usage": 512},
+		{"name": "node2", "ip": "10.10.1.21", "capacity": 1024, "usage": 256}
+	]
+}`
+
+var testClusterDescription = Description{
+	Endpoints: []EndpointDescription{
+		{
+			Path: "/api/cluster",
+			Resources: []ResourceDescription{
+				{
+					Attributes: map[string]string{"cluster_name": "cluster.name"},
+					Metrics: []MetricDescription{
+						{Name: "total_capacity", Unit: "KiBy", ValueType: VALUE_TYPE_INT, Expression: "sum(nodes[*].capacity)"},
+						{Name: "used_capacity", Unit: "KiBy", ValueType: VALUE_TYPE_INT, Expression: "sum(nodes[*].usage)"},
+						{Name: "utilization", Unit: "%", Expression: "used_capacity / total_capacity * 100"},
+					},
+				},
+				{
+					Selector:   "nodes[*]",
+					Attributes: map[string]string{"cluster_name": "$.cluster.name", "node_name": "name", "ip": "ip"},
+					Metrics: []MetricDescription{
+						{Name: "total_capacity", Unit: "KiBy", ValueType: VALUE_TYPE_INT, Field: "capacity"},
+						{Name: "used_capacity", Unit: "KiBy", ValueType: VALUE_TYPE_INT, Field: "usage"},
+						{Name: "utilization", Unit: "%", Expression: "used_capacity / total_capacity * 100"},
+					},
+				},
+			},
+		},
+	},
+}
+
+// findMetric returns the metric with the given name on the resource with exactly the given attributes
+func findMetric(t *testing.T, metrics pmetric.Metrics, attrs map[string]any, metricName string) pmetric.Metric {
+	key := generateResourceKey(attrs)
+	for i := 0; i < metrics.ResourceMetrics().Len(); i++ {
+		rm := metrics.ResourceMetrics().At(i)
+		if generateResourceKey(rm.Resource().Attributes().AsRaw()) != key {
+			continue
+		}
+		ms := rm.ScopeMetrics().At(0).Metrics()
+		for j := 0; j < ms.Len(); j++ {
+			if ms.At(j).Name() == metricName {
+				return ms.At(j)
+			}
+		}
+	}
+	require.Failf(t, "metric not found", "%s on %s", metricName, key)
+	return pmetric.NewMetric()
+}
+
+func TestExtractMetrics(t *testing.T) {
+	var doc any
+	require.NoError(t, json.Unmarshal([]byte(testClusterResponse), &doc))
+	cd, errs := compileDescription(&testClusterDescription)
+	require.Empty(t, errs)
+
+	builder := NewMetricsBuilder()
+	timestamp := time.Now()
+	assert.Empty(t, cd.endpoints[0].extractMetrics(doc, &extraction{builder: builder, now: timestamp}))
+
+	metrics := builder.GetMetrics()
+	assert.Equal(t, 3, metrics.ResourceMetrics().Len())
+
+	cluster := map[string]any{"cluster_name": "cluster1"}
+	node2 := map[string]any{"cluster_name": "cluster1", "node_name": "node2", "ip": "10.10.1.21"}
+	tests := []struct {
+		attrs    map[string]any
+		name     string
+		unit     string
+		intValue bool
+		expected float64
+	}{
+		{attrs: cluster, name: "total_capacity", unit: "KiBy", intValue: true, expected: 3072},
+		{attrs: cluster, name: "used_capacity", unit: "KiBy", intValue: true, expected: 768},
+		{attrs: cluster, name: "utilization", unit: "%", expected: 25},
+		{attrs: node2, name: "total_capacity", unit: "KiBy", intValue: true, expected: 1024},
+		{attrs: node2, name: "utilization", unit: "%", expected: 25},
+	}
+	for _, tt := range tests {
+		m := findMetric(t, metrics, tt.attrs, tt.name)
+		assert.Equal(t, tt.unit, m.Unit())
+		dp := m.Gauge().DataPoints().At(0)
+		if tt.intValue {
+			assert.Equal(t, int64(tt.expected), dp.IntValue(), tt.name)
+		} else {
+			assert.Equal(t, tt.expected, dp.DoubleValue(), tt.name)
+		}
+		assert.Equal(t, pcommon.NewTimestampFromTime(timestamp), dp.Timestamp())
+	}
+}
+
+func TestExtractMetrics_PartialErrors(t *testing.T) {
+	var doc any
+	require.NoError(t, json.Unmarshal([]byte(`{"name": "x", "used": 5, "total": 0}`), &doc))
+	cd, errs := compileDescription(&Description{Endpoints: []EndpointDescription{{
+		Resources: []ResourceDescription{{
+			Attributes: map[string]string{"name": "name"},
+			Metrics: []MetricDescription{
+				{Name: "used", Field: "used"},
+				{Name: "free", Field: "free"},
+				{Name: "utilization", Expression: "used / total"},
+			},
+		}},
+	}}})
+	require.Empty(t, errs)
+
+	builder := NewMetricsBuilder()
+	errList := cd.endpoints[0].extractMetrics(doc, &extraction{builder: builder, now: time.Now()})
+	assert.Len(t, errList, 2)
+	assert.EqualError(t, errList[0], "metric 'free': field 'free' not found")
+	assert.EqualError(t, errList[1], "metric 'utilization': expression 'used / total': division by zero")
+	assert.Equal(t, 1, builder.GetMetrics().MetricCount())
+}
+
+func TestExtractMetrics_UnitConversion(t *testing.T) {
+	var doc any
+	require.NoError(t, json.Unmarshal([]byte(`{"name": "vol1", "size": "12.5 GiB", "free": 1048576, "latency": "250ms", "uptime": "3d4h"}`), &doc))
+	cd, errs := compileDescription(&Description{Endpoints: []EndpointDescription{{
+		Resources: []ResourceDescription{{
+			Attributes: map[string]string{"volume": "name"},
+			Metrics: []MetricDescription{
+				{Name: "size", Unit: "MiBy", Field: "size"},
+				{Name: "free", Unit: "MiBy", SourceUnit: "KiBy", Field: "free"},
+				{Name: "latency", Unit: "s", Field: "latency"},
+				{Name: "uptime", Unit: "h", Field: "uptime"},
+			},
+		}},
+	}}})
+	require.Empty(t, errs)
+
+	builder := NewMetricsBuilder()
+	assert.Empty(t, cd.endpoints[0].extractMetrics(doc, &extraction{builder: builder, now: time.Now()}))
+	volume := map[string]any{"volume": "vol1"}
+	metrics := builder.GetMetrics()
+	assert.Equal(t, 12800.0, findMetric(t, metrics, volume, "size").Gauge().DataPoints().At(0).DoubleValue())
+	assert.Equal(t, 1024.0, findMetric(t, metrics, volume, "free").Gauge().DataPoints().At(0).DoubleValue())
+	assert.Equal(t, 0.25, findMetric(t, metrics, volume, "latency").Gauge().DataPoints().At(0).DoubleValue())
+	assert.Equal(t, 76.0, findMetric(t, metrics, volume, "uptime").Gauge().DataPoints().At(0).DoubleValue())
+}
+
+func TestExtractMetrics_States(t *testing.T) {
+	var doc any
+	require.NoError(t, json.Unmarshal([]byte(`{"pools": [{"name": "p1", "health": "GREEN", "status": "degraded"}, {"name": "p2", "health": "BLUE", "status": "online"}]}`), &doc))
+	cd, errs := compileDescription(&Description{Endpoints: []EndpointDescription{{
+		Resources: []ResourceDescription{{
+			Selector:   "pools[*]",
+			Attributes: map[string]string{"pool": "name"},
+			Metrics: []MetricDescription{
+				{Name: "health", Field: "health", ValueType: VALUE_TYPE_INT, States: &StateMapping{Values: map[string]float64{"GREEN": 0, "YELLOW": 1, "RED": 2}}},
+				{Name: "status", Field: "status", States: &StateMapping{Mode: STATE_MODE_STATE_SET, States: []string{"online", "degraded"}}},
+			},
+		}},
+	}}})
+	require.Empty(t, errs)
+
+	builder := NewMetricsBuilder()
+	errList := cd.endpoints[0].extractMetrics(doc, &extraction{builder: builder, now: time.Now()})
+	require.Len(t, errList, 1)
+	assert.EqualError(t, errList[0], "metric 'health': unknown state 'BLUE'")
+
+	metrics := builder.GetMetrics()
+	p1 := map[string]any{"pool": "p1"}
+	assert.Equal(t, int64(0), findMetric(t, metrics, p1, "health").Gauge().DataPoints().At(0).IntValue())
+	status := findMetric(t, metrics, p1, "status").Gauge().DataPoints()
+	require.Equal(t, 2, status.Len())
+	state, _ := status.At(0).Attributes().Get("state")
+	assert.Equal(t, "degraded", state.Str())
+	assert.Equal(t, int64(1), status.At(0).IntValue())
+	assert.Equal(t, int64(0), status.At(1).IntValue())
+}
+
+func TestExtractMetrics_Timestamps(t *testing.T) {
+	var doc any
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"name": "api",
+		"collected_at": "2024-05-01T10:00:00Z",
+		"requests": 1200,
+		"requests_since": 1714550400000,
+		"latency": 12.5,
+		"latency_at": 1714557660
+	}`), &doc))
+	cd, errs := compileDescription(&Description{Endpoints: []EndpointDescription{{
+		Resources: []ResourceDescription{{
+			Attributes: map[string]string{"service": "name"},
+			Timestamp:  &TimestampDescription{Field: "collected_at"},
+			Metrics: []MetricDescription{
+				{
+					Name: "requests", Field: "requests", Type: METRIC_TYPE_SUM, Monotonic: true, ValueType: VALUE_TYPE_INT,
+					StartTime: &TimestampDescription{Field: "requests_since", Format: TIME_FORMAT_EPOCH_MS},
+				},
+				{Name: "latency", Field: "latency", Timestamp: &TimestampDescription{Field: "latency_at", Format: TIME_FORMAT_EPOCH_S}},
+				{Name: "errors", Expression: "0", Type: METRIC_TYPE_SUM},
+			},
+		}},
+	}}})
+	require.Empty(t, errs)
+
+	builder := NewMetricsBuilder()
+	receiverStart := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	assert.Empty(t, cd.endpoints[0].extractMetrics(doc, &extraction{builder: builder, now: time.Now(), startTime: receiverStart}))
+
+	collectedAt := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	service := map[string]any{"service": "api"}
+	metrics := builder.GetMetrics()
+	requests := findMetric(t, metrics, service, "requests").Sum().DataPoints().At(0)
+	assert.Equal(t, pcommon.NewTimestampFromTime(collectedAt), requests.Timestamp())
+	assert.Equal(t, pcommon.NewTimestampFromTime(time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)), requests.StartTimestamp())
+	latency := findMetric(t, metrics, service, "latency").Gauge().DataPoints().At(0)
+	assert.Equal(t, pcommon.NewTimestampFromTime(collectedAt.Add(time.Minute)), latency.Timestamp())
+	errorsDp := findMetric(t, metrics, service, "errors").Sum().DataPoints().At(0)
+	assert.Equal(t, pcommon.NewTimestampFromTime(receiverStart), errorsDp.StartTimestamp())
+}
+
+func TestExtractMetrics_SeriesDeduplication(t *testing.T) {
+	cd, errs := compileDescription(&Description{Endpoints: []EndpointDescription{{
+		Resources: []ResourceDescription{{
+			Attributes: map[string]string{"array": "name"},
+			Metrics:    []MetricDescription{{Name: "iops", Series: &SeriesDescription{Field: "samples"}}},
+		}},
+	}}})
+	require.Empty(t, errs)
+
+	cache := newSeriesCache()
+	extract := func(endpoint, response string) pmetric.Metrics {
+		var doc any
+		require.NoError(t, json.Unmarshal([]byte(response), &doc))
+		builder := NewMetricsBuilder()
+		assert.Empty(t, cd.endpoints[0].extractMetrics(doc, &extraction{builder: builder, endpoint: endpoint, now: time.Now(), series: cache}))
+		return builder.GetMetrics()
+	}
+
+	metrics := extract("https://array01", `{"name": "a1", "samples": [[100, 1], [110, 2]]}`)
+	assert.Equal(t, 2, metrics.DataPointCount())
+	metrics = extract("https://array01", `{"name": "a1", "samples": [[110, 2], [120, 3]]}`)
+	assert.Equal(t, 1, metrics.DataPointCount())
+	dp := findMetric(t, metrics, map[string]any{"array": "a1"}, "iops").Gauge().DataPoints().At(0)
+	assert.Equal(t, 3.0, dp.DoubleValue())
+	metrics = extract("https://array01", `{"name": "a1", "samples": [[110, 2], [120, 3]]}`)
+	assert.Equal(t, 0, metrics.MetricCount())
+
+	// the same series of another target is a series of its own
+	metrics = extract("https://array02", `{"name": "a1", "samples": [[110, 2], [120, 3]]}`)
+	assert.Equal(t, 2, metrics.DataPointCount())
+}
+
+func TestCompileDescription_Errors(t *testing.T) {
+	_, errs := compileDescription(&Description{Endpoints: []EndpointDescription{{
+		Resources: []ResourceDescription{{
+			Selector: "nodes[",
+			Metrics: []MetricDescription{
+				{Name: "a", Field: "a", Expression: "b"},
+				{Name: "a", Expression: "1 +"},
+				{Field: "c", ValueType: "string"},
+				{Name: "d"},
+				{Name: "e", Field: "e", SourceUnit: "By", Unit: "s"},
+				{Name: "f", Expression: "e", SourceUnit: "By"},
+				{Name: "g", Expression: "1", Monotonic: true, Timestamp: &TimestampDescription{}},
+				{Name: "h", Expression: "1", Type: "histogram"},
+				{Name: "i", Field: "i", Series: &SeriesDescription{Value: "["}},
+			},
+		}},
+	}}})
+	assert.Equal(t, []string{
+		"endpoints[0].resources[0]: malformed index in selector 'nodes['",
+		"endpoints[0].resources[0]: at least one attribute is required",
+		"endpoints[0].resources[0].metrics[0]: only one of 'field' or 'expression' may be set",
+		"endpoints[0].resources[0].metrics[1]: duplicate metric name 'a'",
+		"endpoints[0].resources[0].metrics[1]: expression '1 +': unexpected end of expression",
+		"endpoints[0].resources[0].metrics[2]: 'name' is required",
+		"endpoints[0].resources[0].metrics[2]: unknown value_type 'string'",
+		"endpoints[0].resources[0].metrics[3]: one of 'field', 'expression' or 'series' is required",
+		"endpoints[0].resources[0].metrics[4]: cannot convert 'By' to 's'",
+		"endpoints[0].resources[0].metrics[5]: 'source_unit' requires 'field'",
+		"endpoints[0].resources[0].metrics[6]: 'start_time' and 'monotonic' require type 'sum'",
+		"endpoints[0].resources[0].metrics[6].timestamp: 'field' is required",
+		"endpoints[0].resources[0].metrics[7]: unknown type 'histogram'",
+		"endpoints[0].resources[0].metrics[8].series: 'field' is required",
+		"endpoints[0].resources[0].metrics[8].series: malformed index in selector '['",
+		"endpoints[0].resources[0].metrics[8]: 'series' cannot be combined with 'field' or 'expression'",
+	}, errs)
+}
@@ -25,7 +25,10 @@ func NewFactory() receiver.Factory {
 // createDefaultConfig creates a config with defaults
 func createDefaultConfig() component.Config {
 	return &Config{
-		ControllerConfig: scraperhelper.NewDefaultControllerConfig(),
+		ControllerConfig:    scraperhelper.NewDefaultControllerConfig(),
+		Retry:               NewDefaultRetryConfig(),
+		ConditionalRequests: true,
+		MaxResponseSize:     DEFAULT_MAX_RESPONSE_SIZE,
 	}
 }
 
@@ -41,12 +44,12 @@ func createMetricsReceiver(
 		return nil, errConfigNotRestAPIConfig
 	}
 
-	if err := adjustConfigAndValidate(recvConfig); err != nil {
+	recvConfig, err := adjustConfigAndValidate(recvConfig)
+	if err != nil {
 		return nil, fmt.Errorf("failed to validate added config defaults: %w", err)
 	}
 
-	restapiScraper := newScraper(params.Logger, recvConfig, params)
-	scraper, err := scraperhelper.NewScraper(metadata.Type.String(), restapiScraper.scrape, scraperhelper.WithStart(restapiScraper.start))
+	scraper, err := newMetricsScraper(params, recvConfig)
 	if err != nil {
 		return nil, err
 	}
@@ -54,8 +57,35 @@ func createMetricsReceiver(
 	return scraperhelper.NewScraperControllerReceiver(&recvConfig.ControllerConfig, params, consumer, scraperhelper.AddScraper(scraper))
 }
 
-// adjustConfigAndValidate adds any missing config parameters that have defaults
-func adjustConfigAndValidate(cfg *Config) error {
-	//TODO adjust configs if needed
-	return component.ValidateConfig(cfg)
+// newMetricsScraper creates the scraper of a receiver instance. Every instance, such as those receiver_creator
+// starts for endpoints found by observers, owns its client and connections and releases them at shutdown.
+func newMetricsScraper(params receiver.CreateSettings, cfg *Config) (scraperhelper.Scraper, error) {
+	if cfg.Discovery.enabled() {
+		discoveryScraper := newDiscoveryScraper(params.Logger, cfg, params)
+		return scraperhelper.NewScraper(metadata.Type.String(), discoveryScraper.scrape,
+			scraperhelper.WithStart(discoveryScraper.start), scraperhelper.WithShutdown(discoveryScraper.shutdown))
+	}
+	restapiScraper := newScraper(params.Logger, cfg, params)
+	return scraperhelper.NewScraper(metadata.Type.String(), restapiScraper.scrape,
+		scraperhelper.WithStart(restapiScraper.start), scraperhelper.WithShutdown(restapiScraper.shutdown))
+}
+
+// adjustConfigAndValidate validates the config and returns a copy with its endpoint template and the description
+// of its profile resolved
+func adjustConfigAndValidate(cfg *Config) (*Config, error) {
+	if err := component.ValidateConfig(cfg); err != nil {
+		return nil, err
+	}
+	endpoint, err := cfg.resolveEndpoint()
+	if err != nil {
+		return nil, err
+	}
+	description, err := cfg.resolveDescription()
+	if err != nil {
+		return nil, err
+	}
+	adjusted := *cfg
+	adjusted.Endpoint, adjusted.EndpointTemplate = endpoint, ""
+	adjusted.Profile, adjusted.Description = "", description
+	return &adjusted, nil
 }
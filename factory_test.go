@@ -1,10 +1,22 @@
 package restapireceiver
 
 import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/confmap"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/receiver/receivertest"
 	"go.opentelemetry.io/collector/receiver/scraperhelper"
 
 	"github.com/hgokhale/restapireceiver/internal/metadata"
@@ -28,7 +40,10 @@ func TestNewFactory(t *testing.T) {
 				factory := NewFactory()
 
 				var expectedCfg component.Config = &Config{
-					ControllerConfig: scraperhelper.NewDefaultControllerConfig(),
+					ControllerConfig:    scraperhelper.NewDefaultControllerConfig(),
+					Retry:               NewDefaultRetryConfig(),
+					ConditionalRequests: true,
+					MaxResponseSize:     DEFAULT_MAX_RESPONSE_SIZE,
 				}
 
 				require.Equal(t, expectedCfg, factory.CreateDefaultConfig())
@@ -40,3 +55,85 @@ func TestNewFactory(t *testing.T) {
 		t.Run(tc.desc, tc.testFunc)
 	}
 }
+
+// TestFactory_ReceiverCreatorInstances creates receivers the way receiver_creator does for endpoints found by an
+// observer: the template is applied to the default config, then the endpoint of the observer as `host:port`
+func TestFactory_ReceiverCreatorInstances(t *testing.T) {
+	var open atomic.Int32
+	// newServer serves the cluster under /storage to the token only
+	newServer := func(token string) *httptest.Server {
+		server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/storage/api/cluster" || r.Header.Get(HEADER_KEY_AUTHORIZATION) != token {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte(testClusterResponse))
+		}))
+		server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+			switch state {
+			case http.StateNew:
+				open.Add(1)
+			case http.StateClosed, http.StateHijacked:
+				open.Add(-1)
+			}
+		}
+		server.Start()
+		t.Cleanup(server.Close)
+		return server
+	}
+	// the receiver template of receiver_creator, which adds the observed host:port as endpoint and the
+	// backtick-expanded options of each instance
+	template := map[string]any{
+		"endpoint_template":   "http://{{ .Host }}:{{ .Port }}/storage",
+		"collection_interval": "10ms",
+		"initial_delay":       "0s",
+		"description": map[string]any{"endpoints": []any{map[string]any{
+			"path": "/api/cluster",
+			"resources": []any{map[string]any{
+				"selector":   "nodes[*]",
+				"attributes": map[string]any{"node_name": "name"},
+				"metrics":    []any{map[string]any{"name": "total_capacity", "field": "capacity"}},
+			}},
+		}}},
+	}
+
+	factory := NewFactory()
+	var instances []component.Component
+	var sinks []*consumertest.MetricsSink
+	var endpoints []string
+	for _, token := range []string{"t0k3n-a", "t0k3n-b"} {
+		cfg := factory.CreateDefaultConfig()
+		require.NoError(t, confmap.NewFromStringMap(template).Unmarshal(cfg))
+		observed := strings.TrimPrefix(newServer(token).URL, "http://")
+		require.NoError(t, confmap.NewFromStringMap(map[string]any{"endpoint": observed, "auth_token": token}).Unmarshal(cfg))
+
+		sink := new(consumertest.MetricsSink)
+		rcvr, err := factory.CreateMetricsReceiver(context.Background(), receivertest.NewNopCreateSettings(), cfg, sink)
+		require.NoError(t, err)
+		require.NoError(t, rcvr.Start(context.Background(), componenttest.NewNopHost()))
+		instances, sinks = append(instances, rcvr), append(sinks, sink)
+		endpoints = append(endpoints, "http://"+observed+"/storage")
+	}
+	for i, sink := range sinks {
+		require.Eventually(t, func() bool { return sink.DataPointCount() > 0 }, 5*time.Second, 10*time.Millisecond)
+		attrs := sink.AllMetrics()[0].ResourceMetrics().At(0).Resource().Attributes().AsRaw()
+		assert.Equal(t, endpoints[i], attrs[REQUEST_METRICS_ENDPOINT_ATTRIBUTE])
+	}
+
+	// the endpoints go away: the instances release their connections
+	for _, rcvr := range instances {
+		require.NoError(t, rcvr.Shutdown(context.Background()))
+	}
+	assert.Eventually(t, func() bool { return open.Load() == 0 }, 5*time.Second, 10*time.Millisecond)
+
+	// instances that failed to start, or never started, shut down cleanly
+	cfg := factory.CreateDefaultConfig().(*Config)
+	cfg.Endpoint, cfg.AuthTokenFile = "localhost:10000", "/nonexistent/token"
+	rcvr, err := factory.CreateMetricsReceiver(context.Background(), receivertest.NewNopCreateSettings(), cfg, consumertest.NewNop())
+	require.NoError(t, err)
+	assert.Error(t, rcvr.Start(context.Background(), componenttest.NewNopHost()))
+	assert.NoError(t, rcvr.Shutdown(context.Background()))
+	rcvr, err = factory.CreateMetricsReceiver(context.Background(), receivertest.NewNopCreateSettings(), cfg, consumertest.NewNop())
+	require.NoError(t, err)
+	assert.NoError(t, rcvr.Shutdown(context.Background()))
+}
@@ -0,0 +1,172 @@
+package restapireceiver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+const (
+	DEFAULT_GRAPHQL_CURSOR_VARIABLE = "after"
+	DEFAULT_GRAPHQL_MAX_PAGES       = 100
+)
+
+// GraphQLDescription describes a GraphQL query POSTed to the endpoint path.
+// The `data` of the response is the root that resource selectors apply to.
+type GraphQLDescription struct {
+	Query      string             `mapstructure:"query"`
+	Variables  map[string]any     `mapstructure:"variables"`
+	Pagination *GraphQLPagination `mapstructure:"pagination"`
+}
+
+// GraphQLPagination follows Relay-style cursor pagination. Connection selects the connection object
+// within `data` (e.g. `storage.volumes`); its `pageInfo.endCursor` is passed in CursorVariable
+// (default `after`) while `pageInfo.hasNextPage` is true, and the `edges` / `nodes` of all pages are merged.
+type GraphQLPagination struct {
+	Connection     string `mapstructure:"connection"`
+	CursorVariable string `mapstructure:"cursor_variable"`
+	MaxPages       int    `mapstructure:"max_pages"`
+}
+
+type compiledGraphQL struct {
+	GraphQLDescription
+	connection selector
+}
+
+func compileGraphQL(ep *EndpointDescription) (*compiledGraphQL, []string) {
+	var errs []string
+	c := &compiledGraphQL{GraphQLDescription: *ep.GraphQL}
+	if c.Query == "" {
+		errs = append(errs, "'graphql.query' is required")
+	}
+	if ep.Method != "" || ep.Body != "" || len(ep.Form) > 0 || len(ep.Query) > 0 {
+		errs = append(errs, "'graphql' cannot be combined with 'method', 'body', 'form' or 'query'")
+	}
+	if p := c.Pagination; p != nil {
+		pagination := *p
+		if pagination.CursorVariable == "" {
+			pagination.CursorVariable = DEFAULT_GRAPHQL_CURSOR_VARIABLE
+		}
+		if pagination.MaxPages == 0 {
+			pagination.MaxPages = DEFAULT_GRAPHQL_MAX_PAGES
+		}
+		c.Pagination = &pagination
+		var err error
+		if pagination.Connection == "" {
+			errs = append(errs, "'graphql.pagination.connection' is required")
+		} else if c.connection, err = parseSelector(pagination.Connection); err != nil {
+			errs = append(errs, fmt.Sprintf("graphql.pagination: %v", err))
+		}
+		if pagination.MaxPages < 0 {
+			errs = append(errs, "'graphql.pagination.max_pages' must not be negative")
+		}
+	}
+	return c, errs
+}
+
+// execute runs the query, following pagination, and returns the merged `data`.
+// Entries of the `errors` array are returned as errors alongside whatever data was received.
+func (g *compiledGraphQL) execute(ctx context.Context, client *HttpClientHelper, url string) (any, error) {
+	variables := make(map[string]any, len(g.Variables))
+	for k, v := range g.Variables {
+		variables[k] = v
+	}
+
+	var data any
+	var errs []error
+	for page := 1; ; page++ {
+		pageData, err := g.executePage(ctx, client, url, variables)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		if pageData == nil {
+			break
+		}
+		if data == nil {
+			data = pageData
+		} else {
+			g.mergePage(data, pageData)
+		}
+		if g.Pagination == nil {
+			break
+		}
+		cursor, hasNext := g.nextCursor(pageData)
+		if !hasNext {
+			break
+		}
+		if page >= g.Pagination.MaxPages {
+			errs = append(errs, fmt.Errorf("stopped after %d pages", page))
+			break
+		}
+		variables[g.Pagination.CursorVariable] = cursor
+	}
+	return data, errors.Join(errs...)
+}
+
+func (g *compiledGraphQL) executePage(ctx context.Context, client *HttpClientHelper, url string, variables map[string]any) (any, error) {
+	body, err := json.Marshal(map[string]any{"query": g.Query, "variables": variables})
+	if err != nil {
+		return nil, err
+	}
+	req, err := client.NewPostJsonRequest(url, string(body))
+	if err != nil {
+		return nil, err
+	}
+	response, err := client.ExecuteJsonRequest(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	var errs []error
+	if gqlErrors, ok := response["errors"].([]any); ok {
+		for _, e := range gqlErrors {
+			if obj, ok := e.(map[string]any); ok && obj["message"] != nil {
+				errs = append(errs, fmt.Errorf("graphql error: %v", obj["message"]))
+			} else {
+				errs = append(errs, fmt.Errorf("graphql error: %v", e))
+			}
+		}
+	}
+	return response["data"], errors.Join(errs...)
+}
+
+// nextCursor returns the cursor of the next page when there is one
+func (g *compiledGraphQL) nextCursor(data any) (any, bool) {
+	conn, ok := g.connection.SelectOne(data, data)
+	if !ok {
+		return nil, false
+	}
+	obj, ok := conn.(map[string]any)
+	if !ok {
+		return nil, false
+	}
+	pageInfo, ok := obj["pageInfo"].(map[string]any)
+	if !ok {
+		return nil, false
+	}
+	hasNext, _ := pageInfo["hasNextPage"].(bool)
+	cursor := pageInfo["endCursor"]
+	return cursor, hasNext && cursor != nil
+}
+
+// mergePage appends the edges and nodes of a page to the connection of the first page
+func (g *compiledGraphQL) mergePage(data, page any) {
+	first, ok1 := g.connection.SelectOne(data, data)
+	next, ok2 := g.connection.SelectOne(page, page)
+	if !ok1 || !ok2 {
+		return
+	}
+	firstConn, ok1 := first.(map[string]any)
+	nextConn, ok2 := next.(map[string]any)
+	if !ok1 || !ok2 {
+		return
+	}
+	for _, key := range []string{"edges", "nodes"} {
+		if items, ok := nextConn[key].([]any); ok {
+			existing, _ := firstConn[key].([]any)
+			firstConn[key] = append(existing, items...)
+		}
+	}
+	firstConn["pageInfo"] = nextConn["pageInfo"]
+}
@@ -0,0 +1,114 @@
+package restapireceiver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testVolumesQuery = `query($first: Int, $after: String) {
+  storage { volumes(first: $first, after: $after) { nodes { name size } pageInfo { hasNextPage endCursor } } }
+}`
+
+func TestGraphQL_Execute(t *testing.T) {
+	pages := map[any]string{
+		nil: `{"data": {"storage": {"volumes": {"nodes": [{"name": "vol1", "size": 10}],
+			"pageInfo": {"hasNextPage": true, "endCursor": "c1"}}}}}`,
+		"c1": `{"data": {"storage": {"volumes": {"nodes": [{"name": "vol2", "size": 20}],
+			"pageInfo": {"hasNextPage": false, "endCursor": "c2"}}}}}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/graphql", r.URL.Path)
+		var body struct {
+			Query     string         `json:"query"`
+			Variables map[string]any `json:"variables"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, testVolumesQuery, body.Query)
+		assert.Equal(t, float64(1), body.Variables["first"])
+		w.Write([]byte(pages[body.Variables["after"]]))
+	}))
+	defer server.Close()
+
+	ep := &EndpointDescription{Path: "/graphql", GraphQL: &GraphQLDescription{
+		Query:      testVolumesQuery,
+		Variables:  map[string]any{"first": 1},
+		Pagination: &GraphQLPagination{Connection: "storage.volumes"},
+	}}
+	g, errs := compileGraphQL(ep)
+	require.Empty(t, errs)
+
+	data, err := g.execute(context.Background(), NewHttpClientHelper(), BuildUrl(server.URL, ep.Path))
+	require.NoError(t, err)
+	names, _ := parseSelector("storage.volumes.nodes[*].name")
+	assert.Equal(t, []any{"vol1", "vol2"}, names.Select(data, data))
+	assert.Equal(t, map[string]any{"first": 1}, g.Variables, "configured variables are not modified")
+}
+
+func TestGraphQL_ExecuteErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data": {"volumes": []}, "errors": [{"message": "field 'iops' not found"}, "denied"]}`))
+	}))
+	defer server.Close()
+
+	g, errs := compileGraphQL(&EndpointDescription{GraphQL: &GraphQLDescription{Query: "{ volumes { name } }"}})
+	require.Empty(t, errs)
+	data, err := g.execute(context.Background(), NewHttpClientHelper(), server.URL)
+	assert.Equal(t, map[string]any{"volumes": []any{}}, data)
+	assert.EqualError(t, err, "graphql error: field 'iops' not found\ngraphql error: denied")
+}
+
+func TestGraphQL_ExecuteMaxPages(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Write([]byte(`{"data": {"items": {"edges": [{"node": {}}], "pageInfo": {"hasNextPage": true, "endCursor": "next"}}}}`))
+	}))
+	defer server.Close()
+
+	g, errs := compileGraphQL(&EndpointDescription{GraphQL: &GraphQLDescription{
+		Query:      "{ items { edges { node { id } } } }",
+		Pagination: &GraphQLPagination{Connection: "items", MaxPages: 3},
+	}})
+	require.Empty(t, errs)
+	data, err := g.execute(context.Background(), NewHttpClientHelper(), server.URL)
+	assert.EqualError(t, err, "stopped after 3 pages")
+	assert.Equal(t, 3, calls)
+	assert.Len(t, data.(map[string]any)["items"].(map[string]any)["edges"], 3)
+}
+
+func TestGraphQL_ExecuteInvalidConnection(t *testing.T) {
+	for _, connection := range []string{`null`, `[]`, `"items"`} {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, `{"data": {"items": %s}}`, connection)
+		}))
+		g, errs := compileGraphQL(&EndpointDescription{GraphQL: &GraphQLDescription{
+			Query:      "{ items { edges { node { id } } } }",
+			Pagination: &GraphQLPagination{Connection: "items"},
+		}})
+		require.Empty(t, errs)
+		data, err := g.execute(context.Background(), NewHttpClientHelper(), server.URL)
+		server.Close()
+		require.NoError(t, err, connection)
+		assert.Contains(t, data, "items", "a connection that is not an object ends the pagination")
+	}
+}
+
+func TestCompileGraphQL_Errors(t *testing.T) {
+	_, errs := compileGraphQL(&EndpointDescription{Method: "POST", Query: map[string]string{"a": "b"}, GraphQL: &GraphQLDescription{
+		Pagination: &GraphQLPagination{MaxPages: -1},
+	}})
+	assert.Equal(t, []string{
+		"'graphql.query' is required",
+		"'graphql' cannot be combined with 'method', 'body', 'form' or 'query'",
+		"'graphql.pagination.connection' is required",
+		"'graphql.pagination.max_pages' must not be negative",
+	}, errs)
+}
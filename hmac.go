@@ -0,0 +1,157 @@
+package restapireceiver
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"net/http"
+	"text/template"
+	"time"
+
+	"go.opentelemetry.io/collector/config/configopaque"
+)
+
+const (
+	HMAC_ALGORITHM_SHA256 = "sha256"
+	HMAC_ALGORITHM_SHA512 = "sha512"
+
+	SIGNATURE_ENCODING_HEX    = "hex"
+	SIGNATURE_ENCODING_BASE64 = "base64"
+
+	DEFAULT_HMAC_CANONICAL_REQUEST = "{{.Method}}\n{{.Path}}\n{{.Timestamp}}\n{{.BodyHash}}"
+	DEFAULT_HMAC_SIGNATURE_HEADER  = "X-Signature"
+	DEFAULT_HMAC_TIMESTAMP_HEADER  = "X-Timestamp"
+	DEFAULT_HMAC_KEY_ID_HEADER     = "X-Key-Id"
+)
+
+// HMACConfig signs each request with an HMAC of its canonical form. CanonicalRequest is a Go template over
+// hmacTemplateData, by default the method, path, timestamp and body hash separated by newlines.
+// The signature goes to SignatureHeader, prefixed with SignaturePrefix (e.g. "HMAC-SHA256 "), the timestamp
+// (formatted as epoch_s by default, see TimestampDescription) to TimestampHeader and KeyID to KeyIDHeader.
+type HMACConfig struct {
+	KeyID            string              `mapstructure:"key_id"`
+	Secret           configopaque.String `mapstructure:"secret"`
+	Algorithm        string              `mapstructure:"algorithm"`
+	CanonicalRequest string              `mapstructure:"canonical_request"`
+	Encoding         string              `mapstructure:"encoding"`
+	SignatureHeader  string              `mapstructure:"signature_header"`
+	SignaturePrefix  string              `mapstructure:"signature_prefix"`
+	TimestampHeader  string              `mapstructure:"timestamp_header"`
+	TimestampFormat  string              `mapstructure:"timestamp_format"`
+	KeyIDHeader      string              `mapstructure:"key_id_header"`
+}
+
+// hmacTemplateData is available to the canonical request template
+type hmacTemplateData struct {
+	Method    string
+	Host      string
+	Path      string // escaped path
+	Query     string // raw query string
+	Timestamp string
+	BodyHash  string // hex encoded hash of the body with the configured algorithm
+	KeyID     string
+	header    http.Header
+}
+
+// Header returns a request header, e.g. {{.Header "Content-Type"}}
+func (d *hmacTemplateData) Header(name string) string {
+	return d.header.Get(name)
+}
+
+type hmacSigner struct {
+	cfg      HMACConfig
+	hash     func() hash.Hash
+	template *template.Template
+	now      func() time.Time
+}
+
+func (c *HMACConfig) validate() []string {
+	var errs []string
+	if c.Secret == "" {
+		errs = append(errs, "'auth.hmac.secret' is required")
+	}
+	switch c.Algorithm {
+	case "", HMAC_ALGORITHM_SHA256, HMAC_ALGORITHM_SHA512:
+	default:
+		errs = append(errs, fmt.Sprintf("unsupported 'auth.hmac.algorithm' '%s'", c.Algorithm))
+	}
+	switch c.Encoding {
+	case "", SIGNATURE_ENCODING_HEX, SIGNATURE_ENCODING_BASE64:
+	default:
+		errs = append(errs, fmt.Sprintf("unsupported 'auth.hmac.encoding' '%s'", c.Encoding))
+	}
+	if c.CanonicalRequest != "" {
+		if _, err := template.New("canonical_request").Parse(c.CanonicalRequest); err != nil {
+			errs = append(errs, fmt.Sprintf("invalid 'auth.hmac.canonical_request': %v", err))
+		}
+	}
+	return errs
+}
+
+// newHMACSigner creates the signer of the config, applying defaults
+func newHMACSigner(cfg *HMACConfig) (*hmacSigner, error) {
+	s := &hmacSigner{cfg: *cfg, hash: sha256.New, now: time.Now}
+	if cfg.Algorithm == HMAC_ALGORITHM_SHA512 {
+		s.hash = sha512.New
+	}
+	if s.cfg.CanonicalRequest == "" {
+		s.cfg.CanonicalRequest = DEFAULT_HMAC_CANONICAL_REQUEST
+	}
+	if s.cfg.SignatureHeader == "" {
+		s.cfg.SignatureHeader = DEFAULT_HMAC_SIGNATURE_HEADER
+	}
+	if s.cfg.TimestampHeader == "" {
+		s.cfg.TimestampHeader = DEFAULT_HMAC_TIMESTAMP_HEADER
+	}
+	if s.cfg.TimestampFormat == "" {
+		s.cfg.TimestampFormat = TIME_FORMAT_EPOCH_S
+	}
+	if s.cfg.KeyIDHeader == "" {
+		s.cfg.KeyIDHeader = DEFAULT_HMAC_KEY_ID_HEADER
+	}
+	var err error
+	s.template, err = template.New("canonical_request").Option("missingkey=error").Parse(s.cfg.CanonicalRequest)
+	return s, err
+}
+
+func (s *hmacSigner) Sign(req *http.Request, body []byte) error {
+	bodyHash := s.hash()
+	bodyHash.Write(body)
+	data := &hmacTemplateData{
+		Method:    req.Method,
+		Host:      req.URL.Host,
+		Path:      req.URL.EscapedPath(),
+		Query:     req.URL.RawQuery,
+		Timestamp: formatTime(s.now(), s.cfg.TimestampFormat),
+		BodyHash:  hex.EncodeToString(bodyHash.Sum(nil)),
+		KeyID:     s.cfg.KeyID,
+		header:    req.Header,
+	}
+	if data.Path == "" {
+		data.Path = "/"
+	}
+	var canonical bytes.Buffer
+	if err := s.template.Execute(&canonical, data); err != nil {
+		return fmt.Errorf("failed to build canonical request: %w", err)
+	}
+
+	mac := hmac.New(s.hash, []byte(s.cfg.Secret))
+	mac.Write(canonical.Bytes())
+	var signature string
+	if s.cfg.Encoding == SIGNATURE_ENCODING_BASE64 {
+		signature = base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	} else {
+		signature = hex.EncodeToString(mac.Sum(nil))
+	}
+	req.Header.Set(s.cfg.SignatureHeader, s.cfg.SignaturePrefix+signature)
+	req.Header.Set(s.cfg.TimestampHeader, data.Timestamp)
+	if s.cfg.KeyID != "" {
+		req.Header.Set(s.cfg.KeyIDHeader, s.cfg.KeyID)
+	}
+	return nil
+}
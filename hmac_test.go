@@ -0,0 +1,72 @@
+package restapireceiver
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func expectedHMAC(secret, message string) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(message))
+	return mac.Sum(nil)
+}
+
+func sha256Hex(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestHMACSigner_Sign(t *testing.T) {
+	signer, err := newHMACSigner(&HMACConfig{KeyID: "collector", Secret: "s3cr3t"})
+	require.NoError(t, err)
+	signer.now = func() time.Time { return time.Unix(1714557600, 0) }
+
+	req, _ := http.NewRequest(http.MethodPost, "https://api.example.com/v1/volumes?limit=10", nil)
+	require.NoError(t, signer.Sign(req, []byte(`{"type": "volume"}`)))
+
+	canonical := "POST\n/v1/volumes\n1714557600\n" + sha256Hex(`{"type": "volume"}`)
+	assert.Equal(t, hex.EncodeToString(expectedHMAC("s3cr3t", canonical)), req.Header.Get(DEFAULT_HMAC_SIGNATURE_HEADER))
+	assert.Equal(t, "1714557600", req.Header.Get(DEFAULT_HMAC_TIMESTAMP_HEADER))
+	assert.Equal(t, "collector", req.Header.Get(DEFAULT_HMAC_KEY_ID_HEADER))
+}
+
+func TestHMACSigner_SignCustomCanonicalRequest(t *testing.T) {
+	signer, err := newHMACSigner(&HMACConfig{
+		Secret:           "s3cr3t",
+		CanonicalRequest: `{{.Method}} {{.Host}}{{.Path}}?{{.Query}} {{.Header "Content-Type"}} {{.Timestamp}}`,
+		Encoding:         SIGNATURE_ENCODING_BASE64,
+		SignatureHeader:  "Authorization",
+		SignaturePrefix:  "HMAC-SHA256 ",
+		TimestampHeader:  "Date",
+		TimestampFormat:  TIME_FORMAT_RFC3339,
+	})
+	require.NoError(t, err)
+	signer.now = func() time.Time { return time.Unix(1714557600, 0) }
+
+	req, _ := http.NewRequest(http.MethodGet, "https://api.example.com/v1/volumes?limit=10", nil)
+	req.Header.Set(HEADER_KEY_CONTENT_TYPE, CONTENT_TYPE_JSON)
+	require.NoError(t, signer.Sign(req, nil))
+
+	canonical := "GET api.example.com/v1/volumes?limit=10 application/json 2024-05-01T10:00:00Z"
+	assert.Equal(t, "HMAC-SHA256 "+base64.StdEncoding.EncodeToString(expectedHMAC("s3cr3t", canonical)), req.Header.Get("Authorization"))
+	assert.Equal(t, "2024-05-01T10:00:00Z", req.Header.Get("Date"))
+	assert.Empty(t, req.Header.Get(DEFAULT_HMAC_KEY_ID_HEADER))
+}
+
+func TestHMACConfig_Validate(t *testing.T) {
+	assert.Empty(t, (&HMACConfig{Secret: "s3cr3t"}).validate())
+	assert.Equal(t, []string{
+		"'auth.hmac.secret' is required",
+		"unsupported 'auth.hmac.algorithm' 'md5'",
+		"unsupported 'auth.hmac.encoding' 'base32'",
+		"invalid 'auth.hmac.canonical_request': template: canonical_request:1: unclosed action",
+	}, (&HMACConfig{Algorithm: "md5", Encoding: "base32", CanonicalRequest: "{{.Method"}).validate())
+}
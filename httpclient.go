@@ -4,14 +4,22 @@ import (
 	"bytes"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
+	neturl "net/url"
+	"strconv"
+	"strings"
+	"time"
 )
 
 const (
 	HEADER_KEY_AUTHORIZATION = "Authorization"
 	HEADER_KEY_CONTENT_TYPE  = "Content-Type"
+	HEADER_KEY_RETRY_AFTER   = "Retry-After"
 	CONTENT_TYPE_JSON        = "application/json"
+	CONTENT_TYPE_FORM        = "application/x-www-form-urlencoded"
 )
 
 type HttpClient interface {
@@ -20,6 +28,17 @@ type HttpClient interface {
 type HttpClientHelper struct {
 	Client        HttpClient
 	CommonHeaders map[string]string
+	Retry         RetryConfig
+	RateLimiter   *RateLimiter                                        // optional, limits requests per target host
+	ResponseCache *ResponseCache                                      // optional, revalidates responses with ETag / Last-Modified
+	OnRetry       func(req *http.Request, attempt int, reason string) // called before each retry with the failed attempt
+	MaxRetryDelay time.Duration                                       // bounds retry delays of requests without a deadline, 0 for no bound
+
+	RequestCompression string        // compresses request bodies with gzip or deflate when set
+	MaxResponseSize    int64         // maximum decoded response body size in bytes, 0 for no limit
+	Signer             RequestSigner // optional, signs each attempt of a request right before it is sent
+
+	SensitiveQueryParams []string // query parameters redacted from errors and logs, e.g. API keys
 }
 
 func NewHttpClientHelper() *HttpClientHelper {
@@ -42,28 +61,60 @@ func (h *HttpClientHelper) SetAuthToken(token string) {
 	h.CommonHeaders[HEADER_KEY_AUTHORIZATION] = token
 }
 
-//TODO helper for building url
+// BuildUrl joins the endpoint and a path, defaulting to http:// when the endpoint has no scheme.
+// Unix socket endpoints map to http://localhost, the transport dials the socket.
+func BuildUrl(endpoint, path string) string {
+	if _, ok := unixSocketPath(endpoint); ok {
+		endpoint = "http://" + unixSocketHost
+	} else if !strings.Contains(endpoint, "://") {
+		endpoint = "http://" + endpoint
+	}
+	if path == "" {
+		return endpoint
+	}
+	return strings.TrimRight(endpoint, "/") + "/" + strings.TrimLeft(path, "/")
+}
 
 func (h *HttpClientHelper) NewRequest(method, url string, body io.Reader) (*http.Request, error) {
-	req, err := http.NewRequest(http.MethodGet, url, body)
-	if err == nil {
-		for k, v := range h.CommonHeaders {
-			req.Header.Set(k, v)
-		}
+	return h.newRequest(method, url, body, "")
+}
+
+// newRequest builds a request with the common headers and content type, then compresses it. Requests are
+// signed when they are sent, see attemptRequest.
+func (h *HttpClientHelper) newRequest(method, url string, body io.Reader, contentType string) (*http.Request, error) {
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return req, err
+	}
+	for k, v := range h.CommonHeaders {
+		req.Header.Set(k, v)
+	}
+	if contentType != "" {
+		req.Header.Set(HEADER_KEY_CONTENT_TYPE, contentType)
+	}
+	if h.RequestCompression != "" {
+		err = compressBody(req, h.RequestCompression)
 	}
 	return req, err
 }
 
+// setBody replaces the body of a request, keeping it replayable for retries
+func setBody(req *http.Request, body []byte) {
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(body)), nil }
+	req.ContentLength = int64(len(body))
+}
+
 func (h *HttpClientHelper) NewGetRequest(url string) (*http.Request, error) {
 	return h.NewRequest(http.MethodGet, url, nil)
 }
 
 func (h *HttpClientHelper) NewJsonRequest(method, url, body string) (*http.Request, error) {
-	req, err := h.NewRequest(http.MethodGet, url, bytes.NewBuffer([]byte(body)))
-	if err == nil {
-		req.Header.Set(HEADER_KEY_CONTENT_TYPE, CONTENT_TYPE_JSON)
-	}
-	return req, err
+	return h.newRequest(method, url, bytes.NewBuffer([]byte(body)), CONTENT_TYPE_JSON)
+}
+
+func (h *HttpClientHelper) NewFormRequest(method, url string, form neturl.Values) (*http.Request, error) {
+	return h.newRequest(method, url, strings.NewReader(form.Encode()), CONTENT_TYPE_FORM)
 }
 
 func (h *HttpClientHelper) NewPostJsonRequest(url, body string) (*http.Request, error) {
@@ -75,19 +126,191 @@ func (h *HttpClientHelper) NewPutJsonRequest(url, body string) (*http.Request, e
 }
 
 func (h *HttpClientHelper) ExecuteJsonRequest(req *http.Request) (map[string]interface{}, error) {
-	var ret map[string]interface{} = nil
-	resp, err := h.Client.Do(req)
+	response, err := h.executeJson(req)
 	if err != nil {
-		return ret, err
+		return nil, err
 	}
-	ret = make(map[string]interface{})
-	if resp != nil && resp.Body != nil {
+	ret, ok := response.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("JSON response is not an object")
+	}
+	return ret, nil
+}
+
+// executeJson sends the request and decodes its JSON response, which may be of any type such as a top-level array
+func (h *HttpClientHelper) executeJson(req *http.Request) (any, error) {
+	if h.ResponseCache != nil {
+		h.ResponseCache.addConditionalHeaders(req)
+	}
+	if req.Header.Get(HEADER_KEY_ACCEPT_ENCODING) == "" {
+		req.Header.Set(HEADER_KEY_ACCEPT_ENCODING, acceptEncoding())
+	}
+	resp, err := h.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	stats := requestStatsFromContext(req.Context())
+	if stats != nil {
+		stats.recordResponse(resp.StatusCode, resp.TLS)
+	}
+	if resp.StatusCode == http.StatusNotModified && h.ResponseCache != nil {
+		if cached, ok := h.ResponseCache.lookup(req); ok {
+			if resp.Body != nil {
+				resp.Body.Close()
+			}
+			return cached, nil
+		}
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		if resp.Body != nil {
+			resp.Body.Close()
+		}
+		return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	var ret any = make(map[string]interface{})
+	if resp.Body != nil {
 		defer resp.Body.Close()
-		body, err := io.ReadAll(resp.Body)
+		body, err := readBody(resp, h.MaxResponseSize)
 		if err != nil {
-			return ret, err
+			return nil, err
+		}
+		if stats != nil {
+			stats.recordBody(int64(len(body)))
+		}
+		// an empty body, as of 204 responses, is an empty object; anything else must decode
+		if len(body) > 0 {
+			if err := json.Unmarshal(body, &ret); err != nil {
+				return nil, fmt.Errorf("failed to decode JSON response: %w", err)
+			}
 		}
-		err = json.Unmarshal(body, &ret)
+	}
+	if h.ResponseCache != nil {
+		h.ResponseCache.store(req, resp.Header, ret)
 	}
 	return ret, nil
 }
+
+// Do sends the request, retrying connection errors and retryable status codes according to Retry.
+// Retries stop early when waiting would exceed the deadline of the request context.
+func (h *HttpClientHelper) Do(req *http.Request) (*http.Response, error) {
+	resp, err := h.do(req)
+	var urlErr *neturl.Error
+	if len(h.SensitiveQueryParams) > 0 && errors.As(err, &urlErr) {
+		if u, parseErr := neturl.Parse(urlErr.URL); parseErr == nil {
+			urlErr.URL = h.redactUrl(u)
+		}
+	}
+	return resp, err
+}
+
+// redactUrl returns the URL for logs and errors, without credentials
+func (h *HttpClientHelper) redactUrl(u *neturl.URL) string {
+	if len(h.SensitiveQueryParams) == 0 {
+		return u.Redacted()
+	}
+	redacted := *u
+	query := redacted.Query()
+	for _, name := range h.SensitiveQueryParams {
+		if query.Has(name) {
+			query.Set(name, REDACTED)
+		}
+	}
+	redacted.RawQuery = query.Encode()
+	return redacted.Redacted()
+}
+
+// attemptRequest returns the request to send for an attempt: a copy with a fresh body for retries, signed
+// right before it is sent so that time-bound signatures neither expire while waiting for the rate limiter
+// nor are replayed by retries
+func (h *HttpClientHelper) attemptRequest(req *http.Request, attempt int) (*http.Request, error) {
+	if h.Signer == nil && (attempt == 1 || req.GetBody == nil) {
+		return req, nil
+	}
+	var signedBody []byte
+	if h.Signer != nil {
+		var err error
+		if signedBody, err = requestBody(req); err != nil {
+			return nil, err
+		}
+	}
+	attemptReq := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		attemptReq.Body = body
+	}
+	if h.Signer != nil {
+		if err := h.Signer.Sign(attemptReq, signedBody); err != nil {
+			return nil, err
+		}
+	}
+	return attemptReq, nil
+}
+
+func (h *HttpClientHelper) do(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	for attempt := 1; ; attempt++ {
+		if h.RateLimiter != nil {
+			if err := h.RateLimiter.Wait(ctx, req.URL.Host); err != nil {
+				return nil, err
+			}
+		}
+		attemptReq, err := h.attemptRequest(req, attempt)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := h.Client.Do(attemptReq)
+		if h.RateLimiter != nil && resp != nil {
+			h.RateLimiter.Observe(req.URL.Host, resp.Header)
+		}
+
+		var reason string
+		var delay time.Duration
+		switch {
+		case err != nil:
+			if ctx.Err() != nil {
+				return nil, err
+			}
+			reason = "error"
+		case h.Retry.isRetryableStatus(resp.StatusCode):
+			reason = strconv.Itoa(resp.StatusCode)
+			delay, _ = parseRetryAfter(resp.Header.Get(HEADER_KEY_RETRY_AFTER), time.Now())
+		default:
+			return resp, nil
+		}
+		if attempt >= h.Retry.MaxAttempts {
+			return resp, err
+		}
+		if delay == 0 {
+			delay = h.Retry.backoff(attempt)
+		}
+		// a server's Retry-After is bounded like the backoff, and by MaxRetryDelay without a deadline
+		if h.Retry.MaxInterval > 0 && delay > h.Retry.MaxInterval {
+			delay = h.Retry.MaxInterval
+		}
+		if deadline, ok := ctx.Deadline(); ok {
+			if time.Now().Add(delay).After(deadline) {
+				return resp, err
+			}
+		} else if h.MaxRetryDelay > 0 && delay > h.MaxRetryDelay {
+			delay = h.MaxRetryDelay
+		}
+		if resp != nil && resp.Body != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		if h.OnRetry != nil {
+			h.OnRetry(attemptReq, attempt, reason)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
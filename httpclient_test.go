@@ -2,13 +2,20 @@ package restapireceiver
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"io"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 // MockHTTPClient is a mock HTTP client to simulate responses
@@ -76,6 +83,73 @@ func TestNewJsonRequest(t *testing.T) {
 	assert.Equal(t, body, buf.String())
 }
 
+func TestNewRequestMethods(t *testing.T) {
+	helper := NewHttpClientHelper()
+
+	req, err := helper.NewPostJsonRequest("http://example.com", `{}`)
+	assert.NoError(t, err)
+	assert.Equal(t, http.MethodPost, req.Method)
+
+	req, err = helper.NewPutJsonRequest("http://example.com", `{}`)
+	assert.NoError(t, err)
+	assert.Equal(t, http.MethodPut, req.Method)
+
+	req, err = helper.NewRequest(http.MethodDelete, "http://example.com", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, http.MethodDelete, req.Method)
+
+	req, err = helper.NewFormRequest(http.MethodPost, "http://example.com", url.Values{"filter": {"a b"}})
+	assert.NoError(t, err)
+	assert.Equal(t, http.MethodPost, req.Method)
+	assert.Equal(t, CONTENT_TYPE_FORM, req.Header.Get(HEADER_KEY_CONTENT_TYPE))
+	buf := new(bytes.Buffer)
+	buf.ReadFrom(req.Body)
+	assert.Equal(t, "filter=a+b", buf.String())
+}
+
+type recordingSigner struct {
+	bodies   [][]byte
+	signedAt []time.Time
+}
+
+func (s *recordingSigner) Sign(req *http.Request, body []byte) error {
+	s.bodies = append(s.bodies, body)
+	s.signedAt = append(s.signedAt, time.Now())
+	req.Header.Set("X-Signed", req.Header.Get(HEADER_KEY_CONTENT_TYPE))
+	return nil
+}
+
+func TestDo_Signer(t *testing.T) {
+	signer := &recordingSigner{}
+	var sentAt []time.Time
+	helper := NewHttpClientHelper()
+	helper.Signer = signer
+	helper.RateLimiter = NewRateLimiter(RateLimitConfig{RateLimit: RateLimit{Requests: 1, Period: 100 * time.Millisecond}})
+	helper.Client = &http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		sentAt = append(sentAt, time.Now())
+		assert.Equal(t, CONTENT_TYPE_JSON, req.Header.Get("X-Signed"), "content type is set before signing")
+		body, _ := io.ReadAll(req.Body)
+		assert.Equal(t, `{"type": "volume"}`, string(body), "body is still readable")
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(`{}`))}, nil
+	})}
+
+	// both requests are built up front, the second one then waits for the rate limiter
+	first, err := helper.NewPostJsonRequest("http://example.com", `{"type": "volume"}`)
+	require.NoError(t, err)
+	second, err := helper.NewPostJsonRequest("http://example.com", `{"type": "volume"}`)
+	require.NoError(t, err)
+	assert.Empty(t, signer.bodies, "requests are signed when sent")
+	_, err = helper.ExecuteJsonRequest(first)
+	require.NoError(t, err)
+	_, err = helper.ExecuteJsonRequest(second)
+	require.NoError(t, err)
+
+	assert.Equal(t, [][]byte{[]byte(`{"type": "volume"}`), []byte(`{"type": "volume"}`)}, signer.bodies)
+	require.Len(t, sentAt, 2)
+	assert.GreaterOrEqual(t, signer.signedAt[1].Sub(sentAt[0]), 50*time.Millisecond, "signed after waiting for the rate limiter")
+	assert.Empty(t, first.Header.Get("X-Signed"), "the request of the caller is not modified")
+}
+
 func TestExecuteJsonRequest(t *testing.T) {
 	mockClient := new(MockHTTPClient)
 	helper := NewHttpClientHelper()
@@ -97,3 +171,198 @@ func TestExecuteJsonRequest(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, expectedResponse, response)
 }
+
+func TestBuildUrl(t *testing.T) {
+	assert.Equal(t, "http://localhost:10000/api/v1", BuildUrl("localhost:10000", "api/v1"))
+	assert.Equal(t, "https://example.com/api/v1", BuildUrl("https://example.com/", "/api/v1"))
+	assert.Equal(t, "https://example.com", BuildUrl("https://example.com", ""))
+	assert.Equal(t, "http://localhost/containers/json", BuildUrl("unix:///var/run/docker.sock", "/containers/json"))
+}
+
+func TestExecuteJsonRequest_Retry(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		assert.Equal(t, `{"query": "all"}`, string(body))
+		switch calls.Add(1) {
+		case 1:
+			w.WriteHeader(http.StatusBadGateway)
+		case 2:
+			w.Header().Set(HEADER_KEY_RETRY_AFTER, "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			w.Write([]byte(`{"key": "value"}`))
+		}
+	}))
+	defer server.Close()
+
+	helper := NewHttpClientHelper()
+	helper.Retry = RetryConfig{MaxAttempts: 3, InitialInterval: time.Millisecond, Multiplier: 1, RetryableStatusCodes: []int{502, 503}}
+	var reasons []string
+	helper.OnRetry = func(_ *http.Request, _ int, reason string) {
+		reasons = append(reasons, reason)
+	}
+
+	req, _ := helper.NewPostJsonRequest(server.URL, `{"query": "all"}`)
+	response, err := helper.ExecuteJsonRequest(req)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"key": "value"}, response)
+	assert.Equal(t, []string{"502", "503"}, reasons)
+}
+
+func TestExecuteJsonRequest_RetrySignedAgain(t *testing.T) {
+	signer := newSigV4Signer(&testSigV4Config)
+	now := time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+	signer.now = func() time.Time {
+		now = now.Add(time.Minute)
+		return now
+	}
+	verifier := newSigV4Signer(&testSigV4Config)
+	var dates []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// sign what was received again at its date, as the service would
+		body, _ := io.ReadAll(r.Body)
+		date, _ := time.Parse(sigv4TimeFormat, r.Header.Get(HEADER_KEY_AMZ_DATE))
+		verifier.now = func() time.Time { return date }
+		received, _ := http.NewRequest(r.Method, "http://"+r.Host+r.URL.RequestURI(), nil)
+		received.Header.Set(HEADER_KEY_CONTENT_TYPE, r.Header.Get(HEADER_KEY_CONTENT_TYPE))
+		require.NoError(t, verifier.Sign(received, body))
+		assert.Equal(t, received.Header.Get(HEADER_KEY_AUTHORIZATION), r.Header.Get(HEADER_KEY_AUTHORIZATION))
+		dates = append(dates, r.Header.Get(HEADER_KEY_AMZ_DATE))
+		if len(dates) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(`{"key": "value"}`))
+	}))
+	defer server.Close()
+
+	helper := NewHttpClientHelper()
+	helper.Signer = signer
+	helper.Retry = RetryConfig{MaxAttempts: 2, InitialInterval: time.Millisecond, Multiplier: 1, RetryableStatusCodes: []int{502}}
+	req, _ := helper.NewPostJsonRequest(server.URL, `{"query": "all"}`)
+	_, err := helper.ExecuteJsonRequest(req)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"20150830T123700Z", "20150830T123800Z"}, dates, "the retry is signed with a new date")
+}
+
+func TestExecuteJsonRequest_RetryAfterCapped(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.Header().Set(HEADER_KEY_RETRY_AFTER, "3600")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"key": "value"}`))
+	}))
+	defer server.Close()
+
+	for _, tc := range []struct {
+		name  string
+		retry RetryConfig
+		limit time.Duration
+	}{
+		{"max interval", RetryConfig{MaxAttempts: 2, MaxInterval: 10 * time.Millisecond, RetryableStatusCodes: []int{503}}, 0},
+		{"max retry delay", RetryConfig{MaxAttempts: 2, RetryableStatusCodes: []int{503}}, 10 * time.Millisecond},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			calls.Store(0)
+			helper := NewHttpClientHelper()
+			helper.Retry = tc.retry
+			helper.MaxRetryDelay = tc.limit
+			req, _ := helper.NewGetRequest(server.URL)
+			start := time.Now()
+			_, err := helper.ExecuteJsonRequest(req)
+			require.NoError(t, err)
+			assert.Equal(t, int32(2), calls.Load())
+			assert.Less(t, time.Since(start), time.Second)
+		})
+	}
+}
+
+func TestExecuteJsonRequest_RetryExhausted(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	helper := NewHttpClientHelper()
+	helper.Retry = RetryConfig{MaxAttempts: 2, InitialInterval: time.Millisecond, Multiplier: 1, RetryableStatusCodes: []int{502}}
+	req, _ := helper.NewGetRequest(server.URL)
+	_, err := helper.ExecuteJsonRequest(req)
+	assert.EqualError(t, err, "unexpected status code 502")
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestExecuteJsonRequest_RetryBoundedByDeadline(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set(HEADER_KEY_RETRY_AFTER, "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	helper := NewHttpClientHelper()
+	helper.Retry = NewDefaultRetryConfig()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, _ := helper.NewGetRequest(server.URL)
+	start := time.Now()
+	_, err := helper.ExecuteJsonRequest(req.WithContext(ctx))
+	assert.EqualError(t, err, "unexpected status code 429")
+	assert.Equal(t, int32(1), calls.Load())
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestExecuteJsonRequest_NotAnObject(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"key": "value"}]`))
+	}))
+	defer server.Close()
+
+	helper := NewHttpClientHelper()
+	req, _ := helper.NewGetRequest(server.URL)
+	_, err := helper.ExecuteJsonRequest(req)
+	assert.EqualError(t, err, "JSON response is not an object")
+
+	req, _ = helper.NewGetRequest(server.URL)
+	response, err := helper.executeJson(req)
+	require.NoError(t, err)
+	assert.Equal(t, []any{map[string]any{"key": "value"}}, response)
+}
+
+func TestExecuteJsonRequest_NotRetryable(t *testing.T) {
+	mockClient := new(MockHTTPClient)
+	helper := NewHttpClientHelper()
+	helper.Client = mockClient
+	helper.Retry = NewDefaultRetryConfig()
+
+	req, _ := helper.NewGetRequest("http://example.com")
+	mockClient.On("Do", req).Return(&http.Response{StatusCode: http.StatusNotFound, Body: io.NopCloser(bytes.NewBuffer(nil))}, nil).Once()
+
+	_, err := helper.ExecuteJsonRequest(req)
+	assert.EqualError(t, err, "unexpected status code 404")
+	mockClient.AssertExpectations(t)
+}
+
+func TestExecuteJsonRequest_RateLimited(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	helper := NewHttpClientHelper()
+	helper.RateLimiter = NewRateLimiter(RateLimitConfig{RateLimit: RateLimit{Requests: 1, Period: time.Hour}})
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	req, _ := helper.NewGetRequest(server.URL)
+	_, err := helper.ExecuteJsonRequest(req.WithContext(ctx))
+	assert.NoError(t, err)
+	_, err = helper.ExecuteJsonRequest(req.WithContext(ctx))
+	assert.ErrorContains(t, err, "request budget for '"+req.URL.Host+"' exhausted")
+}
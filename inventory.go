@@ -0,0 +1,286 @@
+package restapireceiver
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/receiver"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+const DEFAULT_INVENTORY_REFRESH_INTERVAL = 5 * time.Minute
+
+// HTTPDiscoveryConfig discovers targets from an inventory API such as a CMDB. Request is described like the
+// endpoints of the description, relative to Endpoint, and is sent with the named discovery Credentials or the
+// receiver's. The last inventory is kept while the API is unavailable, and in CacheFile across restarts.
+// An inventory without targets only replaces the current targets and the cache with AllowEmpty.
+type HTTPDiscoveryConfig struct {
+	Endpoint        string              `mapstructure:"endpoint"`
+	Credentials     string              `mapstructure:"credentials"`
+	Request         EndpointDescription `mapstructure:"request"`
+	RefreshInterval time.Duration       `mapstructure:"refresh_interval"`
+	CacheFile       string              `mapstructure:"cache_file"`
+	AllowEmpty      bool                `mapstructure:"allow_empty"`
+	Targets         TargetMapping       `mapstructure:"targets"`
+}
+
+// TargetMapping maps the objects picked by Selector (empty means the whole response, `[*]` the items of a
+// top-level array) to targets.
+// Endpoint, ID, Credentials (naming discovery credentials) and Labels are selectors relative to each object,
+// or to the root with `$.`. Objects without an endpoint are skipped.
+type TargetMapping struct {
+	Selector    string            `mapstructure:"selector"`
+	Endpoint    string            `mapstructure:"endpoint"`
+	ID          string            `mapstructure:"id"`
+	Credentials string            `mapstructure:"credentials"`
+	Labels      map[string]string `mapstructure:"labels"`
+}
+
+type compiledTargetMapping struct {
+	selector    selector
+	endpoint    selector
+	id          *selector
+	credentials *selector
+	labels      map[string]selector
+}
+
+func (c *HTTPDiscoveryConfig) validate(credentials map[string]CredentialsConfig) []string {
+	var errs []string
+	if c.Endpoint == "" {
+		errs = append(errs, "'discovery.http.endpoint' is required")
+	}
+	if _, ok := credentials[c.Credentials]; c.Credentials != "" && !ok {
+		errs = append(errs, fmt.Sprintf("'discovery.http.credentials': unknown credentials '%s'", c.Credentials))
+	}
+	if c.RefreshInterval < 0 {
+		errs = append(errs, "'discovery.http.refresh_interval' must not be negative")
+	}
+	_, requestErrs := compileInventoryRequest(&c.Request)
+	errs = append(errs, requestErrs...)
+	_, mappingErrs := compileTargetMapping(&c.Targets)
+	return append(errs, mappingErrs...)
+}
+
+func (c *HTTPDiscoveryConfig) refreshInterval() time.Duration {
+	if c.RefreshInterval == 0 {
+		return DEFAULT_INVENTORY_REFRESH_INTERVAL
+	}
+	return c.RefreshInterval
+}
+
+// compileInventoryRequest compiles the described request of the inventory, which has no resources of its own
+func compileInventoryRequest(ep *EndpointDescription) (*compiledEndpoint, []string) {
+	var errs []string
+	ce := &compiledEndpoint{EndpointDescription: *ep}
+	if len(ep.Resources) > 0 || ep.CollectionInterval != 0 {
+		errs = append(errs, "discovery.http.request: 'resources' and 'collection_interval' are not supported")
+	}
+	var reqErrs []string
+	ce.request, reqErrs = compileRequest(ep)
+	if ep.GraphQL != nil {
+		var graphqlErrs []string
+		ce.graphql, graphqlErrs = compileGraphQL(ep)
+		reqErrs = append(reqErrs, graphqlErrs...)
+	}
+	for _, e := range reqErrs {
+		errs = append(errs, fmt.Sprintf("discovery.http.request: %s", e))
+	}
+	return ce, errs
+}
+
+func compileTargetMapping(m *TargetMapping) (*compiledTargetMapping, []string) {
+	var errs []string
+	cm := &compiledTargetMapping{labels: make(map[string]selector)}
+	parse := func(name, path string) selector {
+		sel, err := parseSelector(path)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("discovery.http.targets.%s: %v", name, err))
+		}
+		return sel
+	}
+	cm.selector = parse("selector", m.Selector)
+	if m.Endpoint == "" {
+		errs = append(errs, "'discovery.http.targets.endpoint' is required")
+	} else {
+		cm.endpoint = parse("endpoint", m.Endpoint)
+	}
+	if m.ID != "" {
+		sel := parse("id", m.ID)
+		cm.id = &sel
+	}
+	if m.Credentials != "" {
+		sel := parse("credentials", m.Credentials)
+		cm.credentials = &sel
+	}
+	for name, path := range m.Labels {
+		cm.labels[name] = parse("labels."+name, path)
+	}
+	return cm, errs
+}
+
+// groups maps an inventory response to a target group per object, and returns the number of skipped objects
+func (cm *compiledTargetMapping) groups(root any) ([]targetGroup, int) {
+	var groups []targetGroup
+	skipped := 0
+	for _, item := range cm.selector.Select(root, root) {
+		endpoint := selectString(cm.endpoint, root, item)
+		if endpoint == "" {
+			skipped++
+			continue
+		}
+		g := targetGroup{Targets: []string{endpoint}}
+		if cm.id != nil {
+			g.ID = selectString(*cm.id, root, item)
+		}
+		if cm.credentials != nil {
+			g.Credentials = selectString(*cm.credentials, root, item)
+		}
+		for name, sel := range cm.labels {
+			if v := selectString(sel, root, item); v != "" {
+				if g.Labels == nil {
+					g.Labels = make(map[string]string)
+				}
+				g.Labels[name] = v
+			}
+		}
+		groups = append(groups, g)
+	}
+	return groups, skipped
+}
+
+// selectString returns the first selected scalar as a string, empty if there is none
+func selectString(sel selector, root, item any) string {
+	v, _ := sel.SelectOne(root, item)
+	switch t := v.(type) {
+	case string:
+		return t
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(t)
+	}
+	return ""
+}
+
+// httpTargetSource reads target groups from an inventory API every refresh interval
+type httpTargetSource struct {
+	logger      *zap.Logger
+	cfg         *Config
+	settings    receiver.CreateSettings
+	client      *restapiScraper // scraper of the inventory endpoint, only used for its requests
+	request     *compiledEndpoint
+	mapping     *compiledTargetMapping
+	lastRefresh time.Time
+	loaded      bool
+}
+
+func newHTTPTargetSource(logger *zap.Logger, cfg *Config, settings receiver.CreateSettings) *httpTargetSource {
+	return &httpTargetSource{logger: logger, cfg: cfg, settings: settings}
+}
+
+// start creates the client of the inventory endpoint, with the receiver's retry, proxy and auth settings
+func (h *httpTargetSource) start(ctx context.Context, host component.Host) error {
+	discovery := h.cfg.Discovery.HTTP
+	var errs []string
+	if h.request, errs = compileInventoryRequest(&discovery.Request); len(errs) > 0 {
+		return fmt.Errorf("invalid inventory request: %s", strings.Join(errs, ", "))
+	}
+	if h.mapping, errs = compileTargetMapping(&discovery.Targets); len(errs) > 0 {
+		return fmt.Errorf("invalid target mapping: %s", strings.Join(errs, ", "))
+	}
+	cfg := h.cfg.forEndpoint(discovery.Endpoint, discovery.Credentials)
+	cfg.Description = Description{}
+	cfg.RequestMetrics = false
+	h.client = newScraper(h.logger.With(zap.String("inventory", discovery.Endpoint)), cfg, h.settings)
+	return h.client.start(ctx, host)
+}
+
+func (h *httpTargetSource) shutdown(ctx context.Context) error {
+	if h.client == nil {
+		return nil
+	}
+	return h.client.shutdown(ctx)
+}
+
+// groups fetches the inventory once the refresh interval elapsed. Until an inventory was fetched,
+// the one of the cache file is used if the API is unavailable, or else there are no targets until it is.
+func (h *httpTargetSource) groups(ctx context.Context) ([]targetGroup, bool, error) {
+	now := time.Now()
+	if !h.lastRefresh.IsZero() && now.Sub(h.lastRefresh) < h.cfg.Discovery.HTTP.refreshInterval() {
+		return nil, false, nil
+	}
+	h.lastRefresh = now
+	groups, err := h.fetch(ctx, now)
+	if err == nil {
+		h.loaded = true
+		return groups, true, nil
+	}
+	if h.loaded {
+		return nil, false, err
+	}
+	if h.cfg.Discovery.HTTP.CacheFile != "" {
+		cached, cacheErr := h.readCache()
+		if cacheErr == nil {
+			h.logger.Warn("failed to fetch the inventory, using the cached one", zap.Error(err))
+			h.loaded = true
+			return cached, true, nil
+		}
+		err = fmt.Errorf("%w, and failed to read the cache: %v", err, cacheErr)
+	}
+	// one unavailable inventory does not keep the collector from starting
+	h.logger.Warn("failed to fetch the inventory, no targets until it is available", zap.Error(err))
+	return nil, false, nil
+}
+
+func (h *httpTargetSource) fetch(ctx context.Context, now time.Time) ([]targetGroup, error) {
+	if err := h.client.refreshCredentials(); err != nil {
+		h.logger.Warn("failed to reload the inventory credentials, keeping the previous ones", zap.Error(err))
+	}
+	response, err := h.client.fetch(ctx, h.request, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch the inventory: %w", err)
+	}
+	groups, skipped := h.mapping.groups(response)
+	if skipped > 0 {
+		h.logger.Debug("skipped inventory objects without an endpoint", zap.Int("skipped", skipped))
+	}
+	return groups, nil
+}
+
+// applied saves the inventory the targets were updated from in the cache file
+func (h *httpTargetSource) applied(groups []targetGroup) {
+	h.writeCache(groups)
+}
+
+func (h *httpTargetSource) readCache() ([]targetGroup, error) {
+	data, err := os.ReadFile(h.cfg.Discovery.HTTP.CacheFile)
+	if err != nil {
+		return nil, err
+	}
+	return parseTargetGroups(data)
+}
+
+// writeCache saves the inventory in the target file format; failures only lose the fallback
+func (h *httpTargetSource) writeCache(groups []targetGroup) {
+	path := h.cfg.Discovery.HTTP.CacheFile
+	if path == "" {
+		return
+	}
+	data, err := yaml.Marshal(groups)
+	if err == nil {
+		// replaced atomically so that a crash does not leave a truncated cache
+		tmp := path + ".tmp"
+		if err = os.WriteFile(tmp, data, 0600); err == nil {
+			err = os.Rename(tmp, path)
+		}
+	}
+	if err != nil {
+		h.logger.Warn("failed to write the inventory cache", zap.String("file", path), zap.Error(err))
+	}
+}
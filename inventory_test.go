@@ -0,0 +1,258 @@
+package restapireceiver
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/receiver/receivertest"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestTargetMapping_Groups(t *testing.T) {
+	m, errs := compileTargetMapping(&TargetMapping{
+		Selector:    "results[*]",
+		Endpoint:    "mgmt.url",
+		ID:          "asset",
+		Credentials: "profile",
+		Labels:      map[string]string{"site": "location.site", "rack": "rack", "region": "$.region"},
+	})
+	require.Empty(t, errs)
+	var response any = map[string]any{"region": "eu", "results": []any{
+		map[string]any{"asset": "A1", "mgmt": map[string]any{"url": "https://a1"}, "location": map[string]any{"site": "ams"}, "profile": "lab"},
+		map[string]any{"asset": 1002.0, "mgmt": map[string]any{"url": "https://a2"}, "rack": 12.0},
+		map[string]any{"asset": "A3", "mgmt": map[string]any{}},
+	}}
+	groups, skipped := m.groups(response)
+	assert.Equal(t, 1, skipped)
+	assert.Equal(t, []targetGroup{
+		{Targets: []string{"https://a1"}, ID: "A1", Credentials: "lab", Labels: map[string]string{"site": "ams", "region": "eu"}},
+		{Targets: []string{"https://a2"}, ID: "1002", Labels: map[string]string{"rack": "12", "region": "eu"}},
+	}, groups)
+}
+
+// inventoryServer is a stub CMDB listing the given devices, or the raw response if set, or failing when down.
+// It accepts the token "cmdb" unless another one is set.
+type inventoryServer struct {
+	devices string
+	raw     string
+	token   string
+	down    bool
+	calls   int
+}
+
+func (s *inventoryServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.calls++
+	token := s.token
+	if token == "" {
+		token = "cmdb"
+	}
+	if s.down || r.URL.Path != "/api/devices" || r.URL.Query().Get("type") != "storage" || r.Header.Get(HEADER_KEY_AUTHORIZATION) != token {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	if s.raw != "" {
+		w.Write([]byte(s.raw))
+		return
+	}
+	fmt.Fprintf(w, `{"results": [%s]}`, s.devices)
+}
+
+func TestDiscoveryScraper_HTTPTargets(t *testing.T) {
+	array1, array2 := newClusterServer(t, "default"), newClusterServer(t, "default")
+	stub := &inventoryServer{devices: fmt.Sprintf(`{"asset": "A1", "url": %q, "site": "ams"}, {"asset": "A2", "url": %q}`, array1.URL, array2.URL)}
+	cmdb := httptest.NewServer(stub)
+	defer cmdb.Close()
+
+	cache := filepath.Join(t.TempDir(), "inventory.yaml")
+	cfg := &Config{AuthToken: "default", Description: testClusterDescription, Discovery: DiscoveryConfig{
+		HTTP: &HTTPDiscoveryConfig{
+			Endpoint:        cmdb.URL,
+			Credentials:     "cmdb",
+			Request:         EndpointDescription{Path: "/api/devices", Query: map[string]string{"type": "storage"}},
+			RefreshInterval: time.Nanosecond,
+			CacheFile:       cache,
+			Targets:         TargetMapping{Selector: "results[*]", Endpoint: "url", ID: "asset", Labels: map[string]string{"site": "site"}},
+		},
+		Credentials: map[string]CredentialsConfig{"cmdb": {AuthToken: "cmdb"}},
+	}}
+	require.NoError(t, cfg.Validate())
+	d := newDiscoveryScraper(zap.NewNop(), cfg, receivertest.NewNopCreateSettings())
+	require.NoError(t, d.start(context.Background(), componenttest.NewNopHost()))
+	defer d.shutdown(context.Background())
+
+	metrics, err := d.scrape(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 18, metrics.MetricCount())
+	attrs := metrics.ResourceMetrics().At(0).Resource().Attributes().AsRaw()
+	assert.Equal(t, "A1", attrs[TARGET_ID_ATTRIBUTE])
+	assert.Equal(t, "ams", attrs["site"])
+	assert.Equal(t, []string{"A1", "A2"}, sortedKeys(d.targets))
+
+	// A1 is decommissioned and A2 gets a new management address
+	stub.devices = fmt.Sprintf(`{"asset": "A2", "url": %q}`, array1.URL)
+	_, err = d.scrape(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []string{"A2"}, sortedKeys(d.targets))
+	assert.Equal(t, array1.URL, d.targets["A2"].endpoint)
+
+	// the targets are kept while the CMDB is down
+	stub.down = true
+	metrics, err = d.scrape(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 9, metrics.MetricCount())
+
+	// and taken from the cache at a restart
+	restarted := newDiscoveryScraper(zap.NewNop(), cfg, receivertest.NewNopCreateSettings())
+	require.NoError(t, restarted.start(context.Background(), componenttest.NewNopHost()))
+	defer restarted.shutdown(context.Background())
+	assert.Equal(t, []string{"A2"}, sortedKeys(restarted.targets))
+
+	// without a cache, the receiver starts without targets until the CMDB is back
+	cfg.Discovery.HTTP.CacheFile = ""
+	core, logs := observer.New(zap.WarnLevel)
+	restarted = newDiscoveryScraper(zap.New(core), cfg, receivertest.NewNopCreateSettings())
+	require.NoError(t, restarted.start(context.Background(), componenttest.NewNopHost()))
+	defer restarted.shutdown(context.Background())
+	assert.Empty(t, restarted.targets)
+	require.Equal(t, 1, logs.Len())
+	assert.Equal(t, "failed to fetch the inventory: unexpected status code 503", logs.All()[0].ContextMap()["error"])
+	stub.down = false
+	_, err = restarted.scrape(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"A2"}, sortedKeys(restarted.targets))
+}
+
+func TestDiscoveryScraper_HTTPInvalidInventory(t *testing.T) {
+	array1, array2 := newClusterServer(t, "default"), newClusterServer(t, "default")
+	stub := &inventoryServer{raw: fmt.Sprintf(`[{"asset": "A1", "url": %q}, {"asset": "A2", "url": "unix://api.sock"}]`, array1.URL)}
+	cmdb := httptest.NewServer(stub)
+	defer cmdb.Close()
+
+	cache := filepath.Join(t.TempDir(), "inventory.yaml")
+	cfg := &Config{AuthToken: "default", Description: testClusterDescription, Discovery: DiscoveryConfig{
+		HTTP: &HTTPDiscoveryConfig{
+			Endpoint:        cmdb.URL,
+			Credentials:     "cmdb",
+			Request:         EndpointDescription{Path: "/api/devices", Query: map[string]string{"type": "storage"}},
+			RefreshInterval: time.Nanosecond,
+			CacheFile:       cache,
+			Targets:         TargetMapping{Selector: "[*]", Endpoint: "url", ID: "asset"},
+		},
+		Credentials: map[string]CredentialsConfig{"cmdb": {AuthToken: "cmdb"}},
+	}}
+	d := newDiscoveryScraper(zap.NewNop(), cfg, receivertest.NewNopCreateSettings())
+	require.NoError(t, d.start(context.Background(), componenttest.NewNopHost()))
+	defer d.shutdown(context.Background())
+	assert.Equal(t, []string{"A1"}, sortedKeys(d.targets), "a top-level array is mapped, and the invalid target skipped")
+	cached, err := os.ReadFile(cache)
+	require.NoError(t, err)
+
+	// neither a response that is not JSON nor an inventory without valid targets replaces the targets or the cache
+	for _, raw := range []string{`<html>maintenance</html>`, `[]`, `{"results": []}`, `"ok"`, `[{"url": "unix://api.sock"}]`} {
+		stub.raw = raw
+		assert.Error(t, d.refresh(context.Background()), raw)
+		assert.Equal(t, []string{"A1"}, sortedKeys(d.targets), raw)
+		data, err := os.ReadFile(cache)
+		require.NoError(t, err)
+		assert.Equal(t, string(cached), string(data), raw)
+	}
+
+	stub.raw = fmt.Sprintf(`[{"asset": "A2", "url": %q}]`, array2.URL)
+	require.NoError(t, d.refresh(context.Background()))
+	assert.Equal(t, []string{"A2"}, sortedKeys(d.targets))
+
+	// unless empty inventories are allowed
+	cfg.Discovery.HTTP.AllowEmpty = true
+	stub.raw = `[]`
+	require.NoError(t, d.refresh(context.Background()))
+	assert.Empty(t, d.targets)
+	groups, err := d.source.(*httpTargetSource).readCache()
+	require.NoError(t, err)
+	assert.Empty(t, groups)
+}
+
+func TestDiscoveryScraper_HTTPRotatedCredentials(t *testing.T) {
+	array := newClusterServer(t, "default")
+	stub := &inventoryServer{token: "first", devices: fmt.Sprintf(`{"url": %q}`, array.URL)}
+	cmdb := httptest.NewServer(stub)
+	defer cmdb.Close()
+
+	tokenFile := filepath.Join(t.TempDir(), "cmdb-token")
+	t0 := time.Now().Add(-time.Hour)
+	writeSecret(t, tokenFile, "first", t0)
+	cfg := &Config{AuthToken: "default", Description: testClusterDescription, Discovery: DiscoveryConfig{
+		HTTP: &HTTPDiscoveryConfig{
+			Endpoint:        cmdb.URL,
+			Credentials:     "cmdb",
+			Request:         EndpointDescription{Path: "/api/devices", Query: map[string]string{"type": "storage"}},
+			RefreshInterval: time.Nanosecond,
+			Targets:         TargetMapping{Selector: "results[*]", Endpoint: "url"},
+		},
+		Credentials: map[string]CredentialsConfig{"cmdb": {AuthTokenFile: tokenFile}},
+	}}
+	d := newDiscoveryScraper(zap.NewNop(), cfg, receivertest.NewNopCreateSettings())
+	require.NoError(t, d.start(context.Background(), componenttest.NewNopHost()))
+	defer d.shutdown(context.Background())
+	require.Len(t, d.targets, 1)
+
+	// the rotated token is used from the next refresh on
+	stub.token = "second"
+	writeSecret(t, tokenFile, "second", t0.Add(time.Minute))
+	stub.devices = ""
+	cfg.Discovery.HTTP.AllowEmpty = true
+	require.NoError(t, d.refresh(context.Background()))
+	assert.Empty(t, d.targets)
+}
+
+func TestDiscoveryScraper_HTTPRefreshInterval(t *testing.T) {
+	stub := &inventoryServer{}
+	cmdb := httptest.NewServer(stub)
+	defer cmdb.Close()
+
+	cfg := &Config{AuthToken: "cmdb", Discovery: DiscoveryConfig{HTTP: &HTTPDiscoveryConfig{
+		Endpoint: cmdb.URL,
+		Request:  EndpointDescription{Path: "/api/devices", Query: map[string]string{"type": "storage"}},
+		Targets:  TargetMapping{Selector: "results[*]", Endpoint: "url"},
+	}}}
+	d := newDiscoveryScraper(zap.NewNop(), cfg, receivertest.NewNopCreateSettings())
+	require.NoError(t, d.start(context.Background(), componenttest.NewNopHost()))
+	for i := 0; i < 3; i++ {
+		_, err := d.scrape(context.Background())
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 1, stub.calls)
+}
+
+func TestHTTPDiscoveryConfig_Validate(t *testing.T) {
+	cfg := &HTTPDiscoveryConfig{
+		Credentials:     "cmdb",
+		Request:         EndpointDescription{Path: "/api/devices", Method: "DELETE", Resources: []ResourceDescription{{}}},
+		RefreshInterval: -time.Minute,
+		Targets:         TargetMapping{Selector: "results[", Labels: map[string]string{"site": ""}},
+	}
+	assert.Equal(t, []string{
+		"'discovery.http.endpoint' is required",
+		"'discovery.http.credentials': unknown credentials 'cmdb'",
+		"'discovery.http.refresh_interval' must not be negative",
+		"discovery.http.request: 'resources' and 'collection_interval' are not supported",
+		"discovery.http.request: unsupported method 'DELETE'",
+		"discovery.http.targets.selector: malformed index in selector 'results['",
+		"'discovery.http.targets.endpoint' is required",
+	}, cfg.validate(nil))
+
+	discovery := &DiscoveryConfig{File: &FileDiscoveryConfig{Path: "targets.yaml"}, HTTP: &HTTPDiscoveryConfig{
+		Endpoint: "https://cmdb.example.com",
+		Targets:  TargetMapping{Endpoint: "url"},
+	}}
+	assert.Equal(t, []string{"only one of 'discovery.file' or 'discovery.http' may be set"}, discovery.validate())
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"go.opentelemetry.io/collector/pdata/pcommon"
 	"go.opentelemetry.io/collector/pdata/pmetric"
+	"math"
 	"sort"
 	"strings"
 	"time"
@@ -20,6 +21,12 @@ type resource struct {
 	metrics    []metric
 }
 
+// DataPoint is a single timestamped sample of a metric
+type DataPoint struct {
+	Timestamp time.Time
+	Value     float64
+}
+
 type MetricsBuilder struct {
 	metrics        pmetric.Metrics
 	resourceLookup map[string]*ResourceBuilder // resource key to ResourceMetrics map
@@ -74,6 +81,103 @@ func (rb *ResourceBuilder) AddGaugeMetricInt(metricName, unit string, value int6
 	dp.SetIntValue(value)
 }
 
+func (rb *ResourceBuilder) AddSumMetricDouble(metricName, unit string, value float64, isMonotonic bool, startTimestamp, timestamp time.Time) {
+	dp := rb.createSumMetricDatapoint(metricName, unit, isMonotonic)
+	dp.SetStartTimestamp(pcommon.NewTimestampFromTime(startTimestamp))
+	dp.SetTimestamp(pcommon.NewTimestampFromTime(timestamp))
+	dp.SetDoubleValue(value)
+}
+
+func (rb *ResourceBuilder) AddSumMetricInt(metricName, unit string, value int64, isMonotonic bool, startTimestamp, timestamp time.Time) {
+	dp := rb.createSumMetricDatapoint(metricName, unit, isMonotonic)
+	dp.SetStartTimestamp(pcommon.NewTimestampFromTime(startTimestamp))
+	dp.SetTimestamp(pcommon.NewTimestampFromTime(timestamp))
+	dp.SetIntValue(value)
+}
+
+// AddGaugeMetricSeries adds a gauge with one datapoint per sample
+func (rb *ResourceBuilder) AddGaugeMetricSeries(metricName, unit string, points []DataPoint, isInt bool) {
+	newMetric := rb.ResourceMetrics.ScopeMetrics().At(0).Metrics().AppendEmpty()
+	newMetric.SetName(metricName)
+	newMetric.SetUnit(unit)
+	appendDataPoints(newMetric.SetEmptyGauge().DataPoints(), points, isInt, time.Time{})
+}
+
+// AddSumMetricSeries adds a cumulative sum with one datapoint per sample
+func (rb *ResourceBuilder) AddSumMetricSeries(metricName, unit string, points []DataPoint, isInt, isMonotonic bool, startTimestamp time.Time) {
+	newMetric := rb.ResourceMetrics.ScopeMetrics().At(0).Metrics().AppendEmpty()
+	newMetric.SetName(metricName)
+	newMetric.SetUnit(unit)
+	s := newMetric.SetEmptySum()
+	s.SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+	s.SetIsMonotonic(isMonotonic)
+	appendDataPoints(s.DataPoints(), points, isInt, startTimestamp)
+}
+
+func appendDataPoints(dps pmetric.NumberDataPointSlice, points []DataPoint, isInt bool, startTimestamp time.Time) {
+	for _, p := range points {
+		dp := dps.AppendEmpty()
+		if !startTimestamp.IsZero() {
+			dp.SetStartTimestamp(pcommon.NewTimestampFromTime(startTimestamp))
+		}
+		dp.SetTimestamp(pcommon.NewTimestampFromTime(p.Timestamp))
+		if isInt {
+			dp.SetIntValue(int64(math.Round(p.Value)))
+		} else {
+			dp.SetDoubleValue(p.Value)
+		}
+	}
+}
+
+// AddStateSetMetric adds a gauge with one datapoint per state, 1 for the current state and 0 for the others
+func (rb *ResourceBuilder) AddStateSetMetric(metricName, unit, attributeName string, states []string, current string, timestamp time.Time) {
+	newMetric := rb.ResourceMetrics.ScopeMetrics().At(0).Metrics().AppendEmpty()
+	newMetric.SetName(metricName)
+	newMetric.SetUnit(unit)
+	dps := newMetric.SetEmptyGauge().DataPoints()
+	for _, state := range states {
+		dp := dps.AppendEmpty()
+		dp.SetTimestamp(pcommon.NewTimestampFromTime(timestamp))
+		dp.Attributes().PutStr(attributeName, state)
+		if state == current {
+			dp.SetIntValue(1)
+		} else {
+			dp.SetIntValue(0)
+		}
+	}
+}
+
+// AddGaugeDataPoint adds a datapoint with attributes to the gauge of that name, creating the gauge
+// on first use, so that related values (e.g. per endpoint) share one metric
+func (rb *ResourceBuilder) AddGaugeDataPoint(metricName, unit string, value float64, isInt bool, attributes map[string]any, timestamp time.Time) error {
+	metrics := rb.ResourceMetrics.ScopeMetrics().At(0).Metrics()
+	var gauge pmetric.Gauge
+	found := false
+	for i := 0; i < metrics.Len(); i++ {
+		if m := metrics.At(i); m.Name() == metricName && m.Type() == pmetric.MetricTypeGauge {
+			gauge, found = m.Gauge(), true
+			break
+		}
+	}
+	if !found {
+		newMetric := metrics.AppendEmpty()
+		newMetric.SetName(metricName)
+		newMetric.SetUnit(unit)
+		gauge = newMetric.SetEmptyGauge()
+	}
+	dp := gauge.DataPoints().AppendEmpty()
+	if err := dp.Attributes().FromRaw(attributes); err != nil {
+		return err
+	}
+	dp.SetTimestamp(pcommon.NewTimestampFromTime(timestamp))
+	if isInt {
+		dp.SetIntValue(int64(math.Round(value)))
+	} else {
+		dp.SetDoubleValue(value)
+	}
+	return nil
+}
+
 func (rb *ResourceBuilder) createGaugeMetricDatapoint(metricName, unit string) *pmetric.NumberDataPoint {
 	newMetric := rb.ResourceMetrics.ScopeMetrics().At(0).Metrics().AppendEmpty()
 	newMetric.SetName(metricName)
@@ -83,6 +187,17 @@ func (rb *ResourceBuilder) createGaugeMetricDatapoint(metricName, unit string) *
 	return &dp
 }
 
+func (rb *ResourceBuilder) createSumMetricDatapoint(metricName, unit string, isMonotonic bool) *pmetric.NumberDataPoint {
+	newMetric := rb.ResourceMetrics.ScopeMetrics().At(0).Metrics().AppendEmpty()
+	newMetric.SetName(metricName)
+	newMetric.SetUnit(unit)
+	s := newMetric.SetEmptySum()
+	s.SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+	s.SetIsMonotonic(isMonotonic)
+	dp := s.DataPoints().AppendEmpty()
+	return &dp
+}
+
 func generateResourceKey(attrs map[string]any) string {
 	if attrs == nil || len(attrs) == 0 {
 		return ""
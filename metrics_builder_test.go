@@ -6,6 +6,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
 )
 
 func TestGenerateResourceKey(t *testing.T) {
@@ -115,6 +116,104 @@ func TestResourceBuilder_AddGaugeMetricInt(t *testing.T) {
 	assert.Equal(t, pcommon.NewTimestampFromTime(timestamp), dp.Timestamp())
 }
 
+func TestResourceBuilder_AddSumMetric(t *testing.T) {
+	mb := NewMetricsBuilder()
+	rb, err := mb.GetOrCreateResource(map[string]any{"service": "test-service"}, "scope", "v1")
+	assert.NoError(t, err)
+
+	startTime := time.Now().Add(-time.Hour)
+	timestamp := time.Now()
+	rb.AddSumMetricInt("requests", "{requests}", 42, true, startTime, timestamp)
+	rb.AddSumMetricDouble("energy", "kWh", 1.5, false, startTime, timestamp)
+
+	metrics := rb.ResourceMetrics.ScopeMetrics().At(0).Metrics()
+	assert.Equal(t, 2, metrics.Len())
+
+	requests := metrics.At(0)
+	assert.Equal(t, "requests", requests.Name())
+	assert.Equal(t, pmetric.AggregationTemporalityCumulative, requests.Sum().AggregationTemporality())
+	assert.True(t, requests.Sum().IsMonotonic())
+	dp := requests.Sum().DataPoints().At(0)
+	assert.Equal(t, int64(42), dp.IntValue())
+	assert.Equal(t, pcommon.NewTimestampFromTime(startTime), dp.StartTimestamp())
+	assert.Equal(t, pcommon.NewTimestampFromTime(timestamp), dp.Timestamp())
+
+	energy := metrics.At(1)
+	assert.False(t, energy.Sum().IsMonotonic())
+	assert.Equal(t, 1.5, energy.Sum().DataPoints().At(0).DoubleValue())
+}
+
+func TestResourceBuilder_AddMetricSeries(t *testing.T) {
+	mb := NewMetricsBuilder()
+	rb, err := mb.GetOrCreateResource(map[string]any{"service": "test-service"}, "scope", "v1")
+	assert.NoError(t, err)
+
+	t0 := time.Now()
+	points := []DataPoint{{Timestamp: t0, Value: 1.4}, {Timestamp: t0.Add(time.Second), Value: 2.6}}
+	rb.AddGaugeMetricSeries("iops", "{operations}/s", points, false)
+	rb.AddSumMetricSeries("operations", "{operations}", points, true, true, t0.Add(-time.Hour))
+
+	metrics := rb.ResourceMetrics.ScopeMetrics().At(0).Metrics()
+	assert.Equal(t, 2, metrics.Len())
+
+	gauge := metrics.At(0).Gauge().DataPoints()
+	assert.Equal(t, 2, gauge.Len())
+	assert.Equal(t, 2.6, gauge.At(1).DoubleValue())
+	assert.Equal(t, pcommon.NewTimestampFromTime(t0.Add(time.Second)), gauge.At(1).Timestamp())
+	assert.Equal(t, pcommon.Timestamp(0), gauge.At(1).StartTimestamp())
+
+	sum := metrics.At(1).Sum()
+	assert.True(t, sum.IsMonotonic())
+	assert.Equal(t, int64(3), sum.DataPoints().At(1).IntValue())
+	assert.Equal(t, pcommon.NewTimestampFromTime(t0.Add(-time.Hour)), sum.DataPoints().At(1).StartTimestamp())
+}
+
+func TestResourceBuilder_AddStateSetMetric(t *testing.T) {
+	mb := NewMetricsBuilder()
+	rb, err := mb.GetOrCreateResource(map[string]any{"service": "test-service"}, "scope", "v1")
+	assert.NoError(t, err)
+
+	timestamp := time.Now()
+	rb.AddStateSetMetric("health", "1", "state", []string{"green", "red", "yellow"}, "red", timestamp)
+
+	metrics := rb.ResourceMetrics.ScopeMetrics().At(0).Metrics()
+	assert.Equal(t, 1, metrics.Len())
+	m := metrics.At(0)
+	assert.Equal(t, "health", m.Name())
+	assert.Equal(t, "1", m.Unit())
+
+	dps := m.Gauge().DataPoints()
+	assert.Equal(t, 3, dps.Len())
+	for i, expected := range []int64{0, 1, 0} {
+		dp := dps.At(i)
+		state, ok := dp.Attributes().Get("state")
+		assert.True(t, ok)
+		assert.Equal(t, []string{"green", "red", "yellow"}[i], state.Str())
+		assert.Equal(t, expected, dp.IntValue())
+		assert.Equal(t, pcommon.NewTimestampFromTime(timestamp), dp.Timestamp())
+	}
+}
+
+func TestResourceBuilder_AddGaugeDataPoint(t *testing.T) {
+	mb := NewMetricsBuilder()
+	rb, err := mb.GetOrCreateResource(map[string]any{"service": "test-service"}, "scope", "v1")
+	assert.NoError(t, err)
+
+	timestamp := time.Now()
+	assert.NoError(t, rb.AddGaugeDataPoint("status", "1", 200, true, map[string]any{"path": "/a"}, timestamp))
+	assert.NoError(t, rb.AddGaugeDataPoint("duration", "s", 0.25, false, map[string]any{"path": "/a"}, timestamp))
+	assert.NoError(t, rb.AddGaugeDataPoint("status", "1", 503, true, map[string]any{"path": "/b"}, timestamp))
+
+	metrics := rb.ResourceMetrics.ScopeMetrics().At(0).Metrics()
+	assert.Equal(t, 2, metrics.Len())
+	status := metrics.At(0).Gauge().DataPoints()
+	assert.Equal(t, 2, status.Len())
+	path, _ := status.At(1).Attributes().Get("path")
+	assert.Equal(t, "/b", path.Str())
+	assert.Equal(t, int64(503), status.At(1).IntValue())
+	assert.Equal(t, 0.25, metrics.At(1).Gauge().DataPoints().At(0).DoubleValue())
+}
+
 func TestMetricsBuilder_GetMetrics(t *testing.T) {
 	mb := NewMetricsBuilder()
 
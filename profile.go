@@ -0,0 +1,159 @@
+package restapireceiver
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"go.opentelemetry.io/collector/confmap"
+	"gopkg.in/yaml.v3"
+)
+
+// version of the profile file format understood by this receiver
+const PROFILE_SCHEMA_VERSION = 1
+
+// profileFile is a description shared between collector configs, such as the mapping of a vendor's API.
+// Included files (relative to the including one) are loaded first; endpoints of the including file then
+// replace included endpoints of the same name, or of the same path for endpoints without a name.
+type profileFile struct {
+	SchemaVersion int      `mapstructure:"schema_version"`
+	Include       []string `mapstructure:"include"`
+	Description   `mapstructure:",squash"`
+}
+
+// profileLoader loads profile files and their includes, detecting include cycles
+type profileLoader struct {
+	loading map[string]bool
+}
+
+// loadProfile loads the description of a profile file, or of all .yaml and .yml files of a directory in name order
+func loadProfile(path string) (Description, error) {
+	l := &profileLoader{loading: make(map[string]bool)}
+	return l.loadPath(path)
+}
+
+func (l *profileLoader) loadPath(path string) (Description, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return Description{}, fmt.Errorf("failed to read profile: %w", err)
+	}
+	if !info.IsDir() {
+		return l.loadFile(path)
+	}
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return Description{}, fmt.Errorf("failed to read profile directory: %w", err)
+	}
+	var files []string
+	for _, e := range entries {
+		if ext := filepath.Ext(e.Name()); !e.IsDir() && (ext == ".yaml" || ext == ".yml") {
+			files = append(files, filepath.Join(path, e.Name()))
+		}
+	}
+	if len(files) == 0 {
+		return Description{}, fmt.Errorf("profile directory '%s' contains no .yaml files", path)
+	}
+	sort.Strings(files)
+	var description Description
+	for _, file := range files {
+		d, err := l.loadFile(file)
+		if err != nil {
+			return Description{}, err
+		}
+		description.Endpoints = mergeEndpoints(description.Endpoints, d.Endpoints)
+	}
+	return description, nil
+}
+
+func (l *profileLoader) loadFile(path string) (Description, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return Description{}, err
+	}
+	if l.loading[abs] {
+		return Description{}, fmt.Errorf("profile '%s': include cycle", path)
+	}
+	l.loading[abs] = true
+	defer delete(l.loading, abs)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Description{}, fmt.Errorf("failed to read profile: %w", err)
+	}
+	var raw map[string]any
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return Description{}, fmt.Errorf("profile '%s': %w", path, err)
+	}
+	var profile profileFile
+	if err := confmap.NewFromStringMap(raw).Unmarshal(&profile); err != nil {
+		return Description{}, fmt.Errorf("profile '%s': %w", path, err)
+	}
+	if profile.SchemaVersion != PROFILE_SCHEMA_VERSION {
+		return Description{}, fmt.Errorf("profile '%s': 'schema_version' must be %d", path, PROFILE_SCHEMA_VERSION)
+	}
+
+	var description Description
+	for _, include := range profile.Include {
+		if !filepath.IsAbs(include) {
+			include = filepath.Join(filepath.Dir(path), include)
+		}
+		d, err := l.loadPath(include)
+		if err != nil {
+			return Description{}, fmt.Errorf("profile '%s': %w", path, err)
+		}
+		description.Endpoints = mergeEndpoints(description.Endpoints, d.Endpoints)
+	}
+	description.Endpoints = mergeEndpoints(description.Endpoints, profile.Endpoints)
+	return description, nil
+}
+
+// endpointKey identifies an endpoint for overrides: its name, or its path if unnamed
+func endpointKey(ep *EndpointDescription) string {
+	if ep.Name != "" {
+		return ep.Name
+	}
+	return ep.Path
+}
+
+// mergeEndpoints returns the base endpoints with each replaced by the first override of the same key,
+// followed by the other overrides. Endpoints of the same layer never replace each other.
+func mergeEndpoints(base, overrides []EndpointDescription) []EndpointDescription {
+	merged := append([]EndpointDescription(nil), base...)
+	replaced := make([]bool, len(base))
+	for _, ep := range overrides {
+		i := 0
+		for ; i < len(base); i++ {
+			if !replaced[i] && endpointKey(&base[i]) == endpointKey(&ep) {
+				break
+			}
+		}
+		if i < len(base) {
+			merged[i], replaced[i] = ep, true
+		} else {
+			merged = append(merged, ep)
+		}
+	}
+	return merged
+}
+
+// loadedProfile is the profile a config was validated with, kept so that creating the receiver does not read it again
+type loadedProfile struct {
+	path        string
+	description Description
+}
+
+// resolveDescription returns the endpoints of the profile, if any, overridden by those of the receiver's description
+func (c *Config) resolveDescription() (Description, error) {
+	if c.Profile == "" {
+		return c.Description, nil
+	}
+	if c.loadedProfile == nil || c.loadedProfile.path != c.Profile {
+		profile, err := loadProfile(c.Profile)
+		if err != nil {
+			return Description{}, err
+		}
+		c.loadedProfile = &loadedProfile{path: c.Profile, description: profile}
+	}
+	return Description{Endpoints: mergeEndpoints(c.loadedProfile.description.Endpoints, c.Description.Endpoints)}, nil
+}
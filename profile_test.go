@@ -0,0 +1,147 @@
+package restapireceiver
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeProfile(t *testing.T, path, content string) {
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0700))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+}
+
+const testCommonProfile = `
+schema_version: 1
+endpoints:
+  - path: /api/health
+    resources:
+      - attributes: {system: name}
+        metrics:
+          - {name: healthy, field: ok}
+`
+
+const testVendorProfile = `
+schema_version: 1
+include: [../common/health.yaml]
+endpoints:
+  - name: capacity
+    path: /api/v1/capacity
+    collection_interval: 5m
+    resources:
+      - attributes: {pool: name}
+        metrics:
+          - {name: used, field: used, unit: By}
+  - path: /api/health
+    query: {verbose: "true"}
+    resources:
+      - attributes: {system: name}
+        metrics:
+          - {name: healthy, field: status.ok}
+`
+
+func TestLoadProfile(t *testing.T) {
+	dir := t.TempDir()
+	writeProfile(t, filepath.Join(dir, "common", "health.yaml"), testCommonProfile)
+	writeProfile(t, filepath.Join(dir, "acme", "storage.yaml"), testVendorProfile)
+
+	description, err := loadProfile(filepath.Join(dir, "acme", "storage.yaml"))
+	require.NoError(t, err)
+	require.Len(t, description.Endpoints, 2)
+	assert.Equal(t, "/api/health", description.Endpoints[0].Path)
+	assert.Equal(t, map[string]string{"verbose": "true"}, description.Endpoints[0].Query, "the vendor overrides the included endpoint")
+	assert.Equal(t, "capacity", description.Endpoints[1].Name)
+	assert.Equal(t, 5*time.Minute, description.Endpoints[1].CollectionInterval)
+
+	// a directory is loaded in name order
+	writeProfile(t, filepath.Join(dir, "acme", "zz-site.yml"), `
+schema_version: 1
+endpoints:
+  - name: capacity
+    path: /api/v2/capacity
+    resources:
+      - attributes: {pool: name}
+        metrics:
+          - {name: used, field: used_bytes, unit: By}
+`)
+	writeProfile(t, filepath.Join(dir, "acme", "README.md"), "not a profile")
+	description, err = loadProfile(filepath.Join(dir, "acme"))
+	require.NoError(t, err)
+	require.Len(t, description.Endpoints, 2)
+	assert.Equal(t, "/api/v2/capacity", description.Endpoints[1].Path)
+}
+
+func TestLoadProfile_Errors(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name    string
+		content string
+		errMsg  string
+	}{
+		{"schema version", "endpoints: []", "'schema_version' must be 1"},
+		{"unknown key", "schema_version: 1\nendpoint: []", "has invalid keys: endpoint"},
+		{"invalid yaml", "schema_version: [1", "did not find expected"},
+		{"missing include", "schema_version: 1\ninclude: [missing.yaml]", "failed to read profile: stat"},
+		{"cycle", "schema_version: 1\ninclude: [cycle.yaml]", "include cycle"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writeProfile(t, filepath.Join(dir, "cycle.yaml"), tt.content)
+			_, err := loadProfile(filepath.Join(dir, "cycle.yaml"))
+			assert.ErrorContains(t, err, tt.errMsg)
+		})
+	}
+
+	_, err := loadProfile(t.TempDir())
+	assert.ErrorContains(t, err, "contains no .yaml files")
+}
+
+func TestMergeEndpoints(t *testing.T) {
+	base := []EndpointDescription{{Path: "/graphql", Body: "a"}, {Path: "/graphql", Body: "b"}, {Name: "pools", Path: "/pools"}}
+	merged := mergeEndpoints(base, []EndpointDescription{
+		{Path: "/graphql", Body: "a2"},
+		{Name: "pools", Path: "/v2/pools"},
+		{Path: "/nodes"},
+		{Path: "/nodes", Method: "POST"},
+	})
+	assert.Equal(t, []EndpointDescription{
+		{Path: "/graphql", Body: "a2"}, {Path: "/graphql", Body: "b"}, {Name: "pools", Path: "/v2/pools"},
+		{Path: "/nodes"}, {Path: "/nodes", Method: "POST"},
+	}, merged)
+}
+
+func TestConfig_Profile(t *testing.T) {
+	dir := t.TempDir()
+	writeProfile(t, filepath.Join(dir, "common", "health.yaml"), testCommonProfile)
+	writeProfile(t, filepath.Join(dir, "acme", "storage.yaml"), testVendorProfile)
+
+	cfg := createDefaultConfig().(*Config)
+	cfg.Endpoint, cfg.AuthToken = "https://array01.example.com", "t0k3n"
+	cfg.Profile = filepath.Join(dir, "acme", "storage.yaml")
+	cfg.Description = Description{Endpoints: []EndpointDescription{{
+		Name: "capacity",
+		Path: "/api/v1/capacity",
+		Resources: []ResourceDescription{{
+			Attributes: map[string]string{"pool": "name"},
+			Metrics:    []MetricDescription{{Name: "used", Expression: "used /"}},
+		}},
+	}}}
+	assert.EqualError(t, cfg.Validate(), "Config validation failed: endpoints[1].resources[0].metrics[0]: expression 'used /': unexpected end of expression")
+
+	cfg.Description.Endpoints[0].Resources[0].Metrics[0] = MetricDescription{Name: "used", Field: "used", SourceUnit: "MiBy", Unit: "By"}
+	// the profile was read when validating, creating the receiver does not read it again
+	require.NoError(t, os.RemoveAll(filepath.Join(dir, "acme")))
+	adjusted, err := adjustConfigAndValidate(cfg)
+	require.NoError(t, err)
+	assert.Empty(t, adjusted.Profile)
+	require.Len(t, adjusted.Description.Endpoints, 2)
+	assert.Equal(t, "MiBy", adjusted.Description.Endpoints[1].Resources[0].Metrics[0].SourceUnit)
+	assert.Equal(t, filepath.Join(dir, "acme", "storage.yaml"), cfg.Profile, "the config itself is not modified")
+
+	cfg.Profile = filepath.Join(dir, "missing.yaml")
+	assert.ErrorContains(t, cfg.Validate(), "Config validation failed: 'profile': failed to read profile: stat")
+}
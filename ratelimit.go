@@ -0,0 +1,182 @@
+package restapireceiver
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	HEADER_KEY_RATELIMIT_REMAINING = "X-RateLimit-Remaining"
+	HEADER_KEY_RATELIMIT_RESET     = "X-RateLimit-Reset"
+
+	// reset values above this are epoch seconds rather than seconds to wait
+	rateLimitResetEpochThreshold = 1_000_000_000
+)
+
+// RateLimit allows Requests per Period with bursts of up to Burst requests (default Requests)
+type RateLimit struct {
+	Requests int           `mapstructure:"requests"`
+	Period   time.Duration `mapstructure:"period"`
+	Burst    int           `mapstructure:"burst"`
+}
+
+// RateLimitConfig limits the requests sent to each target host. Hosts overrides the limit for
+// specific hosts (host or host:port as found in the request URL). With Adaptive, requests to a host are
+// paused after it reports X-RateLimit-Remaining: 0 until the time given by X-RateLimit-Reset.
+type RateLimitConfig struct {
+	RateLimit `mapstructure:",squash"`
+	Hosts     map[string]RateLimit `mapstructure:"hosts"`
+	Adaptive  bool                 `mapstructure:"adaptive"`
+}
+
+func (r *RateLimit) validate(prefix string) []string {
+	var errs []string
+	if r.Requests < 0 || r.Burst < 0 {
+		errs = append(errs, fmt.Sprintf("'%s' requests and burst must not be negative", prefix))
+	}
+	if r.Requests > 0 && r.Period <= 0 {
+		errs = append(errs, fmt.Sprintf("'%s.period' must be positive", prefix))
+	}
+	return errs
+}
+
+func (r *RateLimitConfig) validate() []string {
+	errs := r.RateLimit.validate("rate_limit")
+	for host, limit := range r.Hosts {
+		errs = append(errs, limit.validate("rate_limit.hosts."+host)...)
+	}
+	return errs
+}
+
+// enabled reports whether any limiting is configured
+func (r *RateLimitConfig) enabled() bool {
+	return r.Requests > 0 || len(r.Hosts) > 0 || r.Adaptive
+}
+
+// RateLimiter keeps a token bucket per target host
+type RateLimiter struct {
+	cfg     RateLimitConfig
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+	now     func() time.Time
+}
+
+type tokenBucket struct {
+	rate         float64 // tokens per second, 0 means unlimited
+	capacity     float64
+	tokens       float64
+	last         time.Time
+	blockedUntil time.Time
+}
+
+func NewRateLimiter(cfg RateLimitConfig) *RateLimiter {
+	return &RateLimiter{
+		cfg:     cfg,
+		buckets: make(map[string]*tokenBucket),
+		now:     time.Now,
+	}
+}
+
+func (l *RateLimiter) bucket(host string, now time.Time) *tokenBucket {
+	if b, ok := l.buckets[host]; ok {
+		return b
+	}
+	limit := l.cfg.RateLimit
+	if hostLimit, ok := l.cfg.Hosts[host]; ok {
+		limit = hostLimit
+	}
+	b := &tokenBucket{last: now}
+	if limit.Requests > 0 {
+		b.rate = float64(limit.Requests) / limit.Period.Seconds()
+		b.capacity = float64(limit.Burst)
+		if b.capacity == 0 {
+			b.capacity = float64(limit.Requests)
+		}
+		b.tokens = b.capacity
+	}
+	l.buckets[host] = b
+	return b
+}
+
+// reserve takes a token for the host and returns how long the caller has to wait before using it
+func (l *RateLimiter) reserve(host string) (time.Duration, func()) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	b := l.bucket(host, now)
+	var wait time.Duration
+	if b.rate > 0 {
+		b.tokens = math.Min(b.capacity, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+		b.last = now
+		b.tokens--
+		if b.tokens < 0 {
+			wait = time.Duration(-b.tokens / b.rate * float64(time.Second))
+		}
+	}
+	if blocked := b.blockedUntil.Sub(now); blocked > wait {
+		wait = blocked
+	}
+	cancel := func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if b.rate > 0 {
+			b.tokens++
+		}
+	}
+	return wait, cancel
+}
+
+// Wait blocks until a request to the host is allowed. It fails without waiting when the
+// request would not be allowed before the context deadline.
+func (l *RateLimiter) Wait(ctx context.Context, host string) error {
+	wait, cancel := l.reserve(host)
+	if wait <= 0 {
+		return nil
+	}
+	if deadline, ok := ctx.Deadline(); ok && l.now().Add(wait).After(deadline) {
+		cancel()
+		return fmt.Errorf("request budget for '%s' exhausted, next request allowed in %v", host, wait.Round(time.Millisecond))
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		cancel()
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Observe adapts to the rate limit headers of a response from the host
+func (l *RateLimiter) Observe(host string, header http.Header) {
+	if !l.cfg.Adaptive {
+		return
+	}
+	remaining, err := strconv.Atoi(header.Get(HEADER_KEY_RATELIMIT_REMAINING))
+	if err != nil || remaining > 0 {
+		return
+	}
+	reset, err := strconv.ParseFloat(header.Get(HEADER_KEY_RATELIMIT_RESET), 64)
+	if err != nil || reset < 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	var until time.Time
+	if reset > rateLimitResetEpochThreshold {
+		until = time.Unix(int64(reset), 0)
+	} else {
+		until = now.Add(time.Duration(reset * float64(time.Second)))
+	}
+	b := l.bucket(host, now)
+	if until.After(b.blockedUntil) {
+		b.blockedUntil = until
+	}
+}
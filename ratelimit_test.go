@@ -0,0 +1,94 @@
+package restapireceiver
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestRateLimiter returns a limiter driven by a fake clock
+func newTestRateLimiter(cfg RateLimitConfig) (*RateLimiter, *time.Time) {
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	l := NewRateLimiter(cfg)
+	l.now = func() time.Time { return now }
+	return l, &now
+}
+
+func TestRateLimiter_TokenBucket(t *testing.T) {
+	l, now := newTestRateLimiter(RateLimitConfig{RateLimit: RateLimit{Requests: 60, Period: time.Minute, Burst: 2}})
+
+	wait, _ := l.reserve("a:443")
+	assert.Equal(t, time.Duration(0), wait)
+	wait, _ = l.reserve("a:443")
+	assert.Equal(t, time.Duration(0), wait)
+	wait, cancel := l.reserve("a:443")
+	assert.Equal(t, time.Second, wait)
+	cancel()
+
+	// other hosts have their own budget
+	wait, _ = l.reserve("b:443")
+	assert.Equal(t, time.Duration(0), wait)
+
+	*now = now.Add(500 * time.Millisecond)
+	wait, _ = l.reserve("a:443")
+	assert.Equal(t, 500*time.Millisecond, wait)
+}
+
+func TestRateLimiter_HostOverride(t *testing.T) {
+	l, _ := newTestRateLimiter(RateLimitConfig{
+		RateLimit: RateLimit{Requests: 100, Period: time.Second},
+		Hosts:     map[string]RateLimit{"slow:80": {Requests: 1, Period: time.Minute}},
+	})
+
+	wait, _ := l.reserve("slow:80")
+	assert.Equal(t, time.Duration(0), wait)
+	wait, _ = l.reserve("slow:80")
+	assert.Equal(t, time.Minute, wait)
+}
+
+func TestRateLimiter_WaitBeyondDeadline(t *testing.T) {
+	l := NewRateLimiter(RateLimitConfig{RateLimit: RateLimit{Requests: 1, Period: time.Hour}})
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	assert.NoError(t, l.Wait(ctx, "a"))
+	assert.EqualError(t, l.Wait(ctx, "a"), "request budget for 'a' exhausted, next request allowed in 1h0m0s")
+	// the failed attempt did not consume the budget
+	wait, _ := l.reserve("a")
+	assert.InDelta(t, float64(time.Hour), float64(wait), float64(time.Second))
+}
+
+func TestRateLimiter_Adaptive(t *testing.T) {
+	l, _ := newTestRateLimiter(RateLimitConfig{Adaptive: true})
+
+	header := http.Header{}
+	header.Set(HEADER_KEY_RATELIMIT_REMAINING, "5")
+	header.Set(HEADER_KEY_RATELIMIT_RESET, "30")
+	l.Observe("a", header)
+	wait, _ := l.reserve("a")
+	assert.Equal(t, time.Duration(0), wait)
+
+	header.Set(HEADER_KEY_RATELIMIT_REMAINING, "0")
+	l.Observe("a", header)
+	wait, _ = l.reserve("a")
+	assert.Equal(t, 30*time.Second, wait)
+
+	header.Set(HEADER_KEY_RATELIMIT_RESET, "1714557660")
+	l.Observe("b", header)
+	wait, _ = l.reserve("b")
+	assert.Equal(t, time.Minute, wait)
+}
+
+func TestRateLimitConfig_Validate(t *testing.T) {
+	cfg := RateLimitConfig{
+		RateLimit: RateLimit{Requests: 10},
+		Hosts:     map[string]RateLimit{"a": {Requests: -1, Period: time.Second}},
+	}
+	assert.Equal(t, []string{
+		"'rate_limit.period' must be positive",
+		"'rate_limit.hosts.a' requests and burst must not be negative",
+	}, cfg.validate())
+}
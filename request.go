@@ -0,0 +1,141 @@
+package restapireceiver
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	neturl "net/url"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// requestTemplateData is available to templated request bodies, form fields and query parameters
+type requestTemplateData struct {
+	Now         time.Time     // time of the scrape
+	LastFetched time.Time     // time of the previous successful fetch of the endpoint, zero on the first one
+	Interval    time.Duration // collection interval of the endpoint
+}
+
+var requestTemplateFuncs = template.FuncMap{
+	"unix":      func(t time.Time) int64 { return t.Unix() },
+	"unixMilli": func(t time.Time) int64 { return t.UnixMilli() },
+	"rfc3339":   func(t time.Time) string { return t.UTC().Format(time.RFC3339) },
+	"ago":       func(d time.Duration, t time.Time) time.Time { return t.Add(-d) },
+	"json": func(v any) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
+
+// compiledRequest holds the parsed templates of an endpoint request
+type compiledRequest struct {
+	method string
+	body   *template.Template
+	form   map[string]*template.Template
+	query  map[string]*template.Template
+}
+
+func compileRequest(ep *EndpointDescription) (*compiledRequest, []string) {
+	var errs []string
+	cr := &compiledRequest{
+		method: strings.ToUpper(ep.Method),
+		form:   make(map[string]*template.Template),
+		query:  make(map[string]*template.Template),
+	}
+	switch cr.method {
+	case "":
+		cr.method = http.MethodGet
+	case http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch:
+	default:
+		errs = append(errs, fmt.Sprintf("unsupported method '%s'", ep.Method))
+	}
+	if ep.Body != "" && len(ep.Form) > 0 {
+		errs = append(errs, "only one of 'body' or 'form' may be set")
+	}
+	if cr.method == http.MethodGet && (ep.Body != "" || len(ep.Form) > 0) {
+		errs = append(errs, "'body' and 'form' require a method other than GET")
+	}
+
+	parse := func(name, text string) *template.Template {
+		t, err := template.New(name).Funcs(requestTemplateFuncs).Option("missingkey=error").Parse(text)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("invalid template: %v", err))
+		}
+		return t
+	}
+	if ep.Body != "" {
+		cr.body = parse("body", ep.Body)
+	}
+	for k, v := range ep.Form {
+		cr.form[k] = parse("form."+k, v)
+	}
+	for k, v := range ep.Query {
+		cr.query[k] = parse("query."+k, v)
+	}
+	return cr, errs
+}
+
+func renderTemplate(t *template.Template, data *requestTemplateData) (string, error) {
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func renderValues(templates map[string]*template.Template, data *requestTemplateData) (neturl.Values, error) {
+	values := neturl.Values{}
+	keys := make([]string, 0, len(templates))
+	for k := range templates {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		v, err := renderTemplate(templates[k], data)
+		if err != nil {
+			return nil, err
+		}
+		values.Set(k, v)
+	}
+	return values, nil
+}
+
+// newRequest builds the request of the endpoint against the given base endpoint
+func (ce *compiledEndpoint) newRequest(client *HttpClientHelper, endpoint string, data *requestTemplateData) (*http.Request, error) {
+	rawUrl := BuildUrl(endpoint, ce.Path)
+	if len(ce.request.query) > 0 {
+		u, err := neturl.Parse(rawUrl)
+		if err != nil {
+			return nil, err
+		}
+		query, err := renderValues(ce.request.query, data)
+		if err != nil {
+			return nil, err
+		}
+		existing := u.Query()
+		for k, v := range query {
+			existing[k] = v
+		}
+		u.RawQuery = existing.Encode()
+		rawUrl = u.String()
+	}
+
+	switch {
+	case ce.request.body != nil:
+		body, err := renderTemplate(ce.request.body, data)
+		if err != nil {
+			return nil, err
+		}
+		return client.NewJsonRequest(ce.request.method, rawUrl, body)
+	case len(ce.request.form) > 0:
+		form, err := renderValues(ce.request.form, data)
+		if err != nil {
+			return nil, err
+		}
+		return client.NewFormRequest(ce.request.method, rawUrl, form)
+	}
+	return client.NewRequest(ce.request.method, rawUrl, nil)
+}
@@ -0,0 +1,93 @@
+package restapireceiver
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEndpoint_NewRequest(t *testing.T) {
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	data := &requestTemplateData{Now: now, LastFetched: now.Add(-time.Minute), Interval: time.Minute}
+	helper := NewHttpClientHelper()
+
+	tests := []struct {
+		name        string
+		endpoint    EndpointDescription
+		method      string
+		url         string
+		contentType string
+		body        string
+	}{
+		{
+			name:     "Get",
+			endpoint: EndpointDescription{Path: "/api/stats"},
+			method:   http.MethodGet,
+			url:      "http://localhost/api/stats",
+		},
+		{
+			name: "Query",
+			endpoint: EndpointDescription{Path: "/api/stats?fields=all", Query: map[string]string{
+				"since": "{{ unix .LastFetched }}",
+				"until": "{{ .Now | unixMilli }}",
+			}},
+			method: http.MethodGet,
+			url:    "http://localhost/api/stats?fields=all&since=1714557540&until=1714557600000",
+		},
+		{
+			name: "JsonBody",
+			endpoint: EndpointDescription{
+				Path:   "/api/search",
+				Method: "post",
+				Body:   `{"from": "{{ rfc3339 (ago .Interval .Now) }}", "filter": {{ json "type:\"volume\"" }}}`,
+			},
+			method:      http.MethodPost,
+			url:         "http://localhost/api/search",
+			contentType: CONTENT_TYPE_JSON,
+			body:        `{"from": "2024-05-01T09:59:00Z", "filter": "type:\"volume\""}`,
+		},
+		{
+			name:        "Form",
+			endpoint:    EndpointDescription{Path: "/api/query", Method: http.MethodPut, Form: map[string]string{"q": "volumes", "at": "{{ unix .Now }}"}},
+			method:      http.MethodPut,
+			url:         "http://localhost/api/query",
+			contentType: CONTENT_TYPE_FORM,
+			body:        "at=1714557600&q=volumes",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cr, errs := compileRequest(&tt.endpoint)
+			require.Empty(t, errs)
+			ce := &compiledEndpoint{EndpointDescription: tt.endpoint, request: cr}
+
+			req, err := ce.newRequest(helper, "localhost", data)
+			require.NoError(t, err)
+			assert.Equal(t, tt.method, req.Method)
+			assert.Equal(t, tt.url, req.URL.String())
+			assert.Equal(t, tt.contentType, req.Header.Get(HEADER_KEY_CONTENT_TYPE))
+			if tt.body != "" {
+				buf := new(bytes.Buffer)
+				buf.ReadFrom(req.Body)
+				assert.Equal(t, tt.body, buf.String())
+			}
+		})
+	}
+}
+
+func TestCompileRequest_Errors(t *testing.T) {
+	_, errs := compileRequest(&EndpointDescription{Method: "TRACE"})
+	assert.Equal(t, []string{"unsupported method 'TRACE'"}, errs)
+
+	_, errs = compileRequest(&EndpointDescription{Body: "{}", Form: map[string]string{"a": "b"}})
+	assert.Equal(t, []string{"only one of 'body' or 'form' may be set", "'body' and 'form' require a method other than GET"}, errs)
+
+	_, errs = compileRequest(&EndpointDescription{Method: http.MethodPost, Body: "{{ .Now "})
+	require.Len(t, errs, 1)
+	assert.Contains(t, errs[0], "invalid template")
+}
@@ -0,0 +1,133 @@
+package restapireceiver
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+const (
+	REQUEST_METRIC_STATUS      = "restapi.request.status"
+	REQUEST_METRIC_DURATION    = "restapi.request.duration"
+	REQUEST_METRIC_BODY_SIZE   = "restapi.response.size"
+	REQUEST_METRIC_CERT_EXPIRY = "restapi.tls.cert.expiry"
+
+	// resource attribute identifying the target of the request metrics
+	REQUEST_METRICS_ENDPOINT_ATTRIBUTE = "restapi.endpoint"
+)
+
+// request phases reported as the `phase` attribute of restapi.request.duration
+var requestPhases = []string{"dns", "connect", "tls", "first_byte", "total"}
+
+// requestStats collects the outcome and timings of the requests made for one endpoint fetch.
+// Phase timings are those of the last attempt; the total covers all attempts and pages.
+type requestStats struct {
+	mu         sync.Mutex
+	start      time.Time
+	phases     map[string]time.Duration
+	phaseStart map[string]time.Time
+	statusCode int
+	bodySize   int64
+	tls        *tls.ConnectionState
+}
+
+type requestStatsKey struct{}
+
+// withRequestStats returns a context that collects the stats of the requests made with it
+func withRequestStats(ctx context.Context) (context.Context, *requestStats) {
+	stats := &requestStats{start: time.Now(), phases: make(map[string]time.Duration), phaseStart: make(map[string]time.Time)}
+	ctx = context.WithValue(ctx, requestStatsKey{}, stats)
+	return httptrace.WithClientTrace(ctx, stats.trace()), stats
+}
+
+func requestStatsFromContext(ctx context.Context) *requestStats {
+	stats, _ := ctx.Value(requestStatsKey{}).(*requestStats)
+	return stats
+}
+
+func (s *requestStats) begin(phase string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.phaseStart[phase] = time.Now()
+}
+
+func (s *requestStats) end(phase string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if start, ok := s.phaseStart[phase]; ok {
+		s.phases[phase] = time.Since(start)
+	}
+}
+
+func (s *requestStats) trace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		GetConn: func(string) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			// a new attempt, drop the timings of the previous one
+			s.phases = make(map[string]time.Duration)
+			s.phaseStart = map[string]time.Time{"first_byte": time.Now()}
+		},
+		DNSStart:             func(httptrace.DNSStartInfo) { s.begin("dns") },
+		DNSDone:              func(httptrace.DNSDoneInfo) { s.end("dns") },
+		ConnectStart:         func(string, string) { s.begin("connect") },
+		ConnectDone:          func(string, string, error) { s.end("connect") },
+		TLSHandshakeStart:    func() { s.begin("tls") },
+		TLSHandshakeDone:     func(tls.ConnectionState, error) { s.end("tls") },
+		GotFirstResponseByte: func() { s.end("first_byte") },
+	}
+}
+
+// recordResponse is called by HttpClientHelper for every response
+func (s *requestStats) recordResponse(statusCode int, state *tls.ConnectionState) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.statusCode = statusCode
+	if state != nil {
+		s.tls = state
+	}
+}
+
+// recordBody is called by HttpClientHelper with the size of every response body read
+func (s *requestStats) recordBody(size int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.bodySize += size
+}
+
+// emit adds the request metrics to the resource of the target. Status 0 means no response was received.
+func (s *requestStats) emit(rb *ResourceBuilder, attributes map[string]any, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.phases["total"] = now.Sub(s.start)
+	if err := rb.AddGaugeDataPoint(REQUEST_METRIC_STATUS, "1", float64(s.statusCode), true, attributes, now); err != nil {
+		return err
+	}
+	for _, phase := range requestPhases {
+		d, ok := s.phases[phase]
+		if !ok {
+			continue
+		}
+		phaseAttributes := map[string]any{"phase": phase}
+		for k, v := range attributes {
+			phaseAttributes[k] = v
+		}
+		if err := rb.AddGaugeDataPoint(REQUEST_METRIC_DURATION, "s", d.Seconds(), false, phaseAttributes, now); err != nil {
+			return err
+		}
+	}
+	if s.statusCode != 0 {
+		if err := rb.AddGaugeDataPoint(REQUEST_METRIC_BODY_SIZE, "By", float64(s.bodySize), true, attributes, now); err != nil {
+			return err
+		}
+	}
+	if s.tls != nil && len(s.tls.PeerCertificates) > 0 {
+		days := s.tls.PeerCertificates[0].NotAfter.Sub(now).Hours() / 24
+		if err := rb.AddGaugeDataPoint(REQUEST_METRIC_CERT_EXPIRY, "d", days, false, attributes, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
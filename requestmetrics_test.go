@@ -0,0 +1,114 @@
+package restapireceiver
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+)
+
+// gaugeValues returns the values of a gauge by the given datapoint attribute
+func gaugeValues(t *testing.T, metrics pmetric.MetricSlice, name, attribute string) map[string]float64 {
+	values := make(map[string]float64)
+	for i := 0; i < metrics.Len(); i++ {
+		if metrics.At(i).Name() != name {
+			continue
+		}
+		dps := metrics.At(i).Gauge().DataPoints()
+		for j := 0; j < dps.Len(); j++ {
+			key, _ := dps.At(j).Attributes().Get(attribute)
+			if dps.At(j).ValueType() == pmetric.NumberDataPointValueTypeInt {
+				values[key.AsString()] = float64(dps.At(j).IntValue())
+			} else {
+				values[key.AsString()] = dps.At(j).DoubleValue()
+			}
+		}
+		return values
+	}
+	t.Fatalf("metric %s not found", name)
+	return nil
+}
+
+func TestRequestStats_Emit(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"key": "value"}`))
+	}))
+	defer server.Close()
+
+	helper := NewHttpClientHelper()
+	helper.Client = server.Client()
+	ctx, stats := withRequestStats(context.Background())
+	req, _ := helper.NewGetRequest(server.URL)
+	_, err := helper.ExecuteJsonRequest(req.WithContext(ctx))
+	require.NoError(t, err)
+
+	rb, _ := NewMetricsBuilder().GetOrCreateResource(map[string]any{REQUEST_METRICS_ENDPOINT_ATTRIBUTE: server.URL}, SCOPE_NAME, SCOPE_VERSION)
+	require.NoError(t, stats.emit(rb, map[string]any{"path": "/"}, time.Now()))
+	metrics := rb.ScopeMetrics().At(0).Metrics()
+
+	assert.Equal(t, map[string]float64{"/": 200}, gaugeValues(t, metrics, REQUEST_METRIC_STATUS, "path"))
+	assert.Equal(t, map[string]float64{"/": 16}, gaugeValues(t, metrics, REQUEST_METRIC_BODY_SIZE, "path"))
+	phases := gaugeValues(t, metrics, REQUEST_METRIC_DURATION, "phase")
+	for _, phase := range []string{"connect", "tls", "first_byte", "total"} {
+		assert.Contains(t, phases, phase)
+	}
+	assert.NotContains(t, phases, "dns", "no lookup for an IP address")
+	assert.GreaterOrEqual(t, phases["total"], phases["first_byte"])
+	expiry := gaugeValues(t, metrics, REQUEST_METRIC_CERT_EXPIRY, "path")["/"]
+	assert.Greater(t, expiry, 365.0)
+}
+
+func TestRequestStats_EmitFailedRequest(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	endpoint := server.URL
+	server.Close()
+
+	helper := NewHttpClientHelper()
+	ctx, stats := withRequestStats(context.Background())
+	req, _ := helper.NewGetRequest(endpoint)
+	_, err := helper.ExecuteJsonRequest(req.WithContext(ctx))
+	require.Error(t, err)
+
+	rb, _ := NewMetricsBuilder().GetOrCreateResource(map[string]any{REQUEST_METRICS_ENDPOINT_ATTRIBUTE: endpoint}, SCOPE_NAME, SCOPE_VERSION)
+	require.NoError(t, stats.emit(rb, map[string]any{"path": "/"}, time.Now()))
+	metrics := rb.ScopeMetrics().At(0).Metrics()
+	assert.Equal(t, map[string]float64{"/": 0}, gaugeValues(t, metrics, REQUEST_METRIC_STATUS, "path"))
+	assert.Equal(t, 2, metrics.Len(), "status and duration only")
+}
+
+func TestScraper_ScrapeRequestMetrics(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(testClusterResponse))
+	}))
+	defer server.Close()
+
+	description := testClusterDescription
+	description.Endpoints = append([]EndpointDescription{}, description.Endpoints...)
+	description.Endpoints = append(description.Endpoints, EndpointDescription{Path: "/missing"})
+	s := newTestScraper(t, &Config{Endpoint: server.URL, AuthToken: "testtoken", RequestMetrics: true, Description: description})
+	metrics, err := s.scrape(context.Background())
+	require.Error(t, err)
+
+	// the payload resources name the target too, so that they can be joined with its request metrics
+	var target pmetric.ResourceMetrics
+	for i := 0; i < metrics.ResourceMetrics().Len(); i++ {
+		rm := metrics.ResourceMetrics().At(i)
+		v, _ := rm.Resource().Attributes().Get(REQUEST_METRICS_ENDPOINT_ATTRIBUTE)
+		assert.Equal(t, server.URL, v.Str())
+		if rm.Resource().Attributes().Len() == 1 {
+			target = rm
+		}
+	}
+	require.NotEqual(t, pmetric.ResourceMetrics{}, target)
+	assert.Equal(t, map[string]float64{"/api/cluster": 200, "/missing": 404},
+		gaugeValues(t, target.ScopeMetrics().At(0).Metrics(), REQUEST_METRIC_STATUS, "path"))
+}
@@ -0,0 +1,105 @@
+package restapireceiver
+
+import (
+	"context"
+	"net/http"
+	"sync"
+)
+
+const (
+	HEADER_KEY_ETAG              = "ETag"
+	HEADER_KEY_LAST_MODIFIED     = "Last-Modified"
+	HEADER_KEY_IF_NONE_MATCH     = "If-None-Match"
+	HEADER_KEY_IF_MODIFIED_SINCE = "If-Modified-Since"
+)
+
+// ResponseCache keeps the last parsed response of each URL together with its validators,
+// so that unchanged responses can be revalidated with conditional requests instead of re-downloaded.
+// Requests made with withResponseCacheKey share one entry, replaced when their URL changes, so that
+// URLs templated with the time do not add an entry at every scrape.
+type ResponseCache struct {
+	mu      sync.Mutex
+	entries map[any]*responseCacheEntry
+}
+
+type responseCacheEntry struct {
+	url          string
+	etag         string
+	lastModified string
+	response     any
+}
+
+type responseCacheKey struct{}
+
+func NewResponseCache() *ResponseCache {
+	return &ResponseCache{entries: make(map[any]*responseCacheEntry)}
+}
+
+// withResponseCacheKey returns a context whose requests are cached under the key, such as their endpoint
+func withResponseCacheKey(ctx context.Context, key any) context.Context {
+	return context.WithValue(ctx, responseCacheKey{}, key)
+}
+
+// entry returns the key of the request, and its entry if one was stored for the same URL
+func (c *ResponseCache) entry(req *http.Request) (any, *responseCacheEntry) {
+	var key any = req.URL.String()
+	if k := req.Context().Value(responseCacheKey{}); k != nil {
+		key = k
+	}
+	entry, ok := c.entries[key]
+	if !ok || entry.url != req.URL.String() {
+		return key, nil
+	}
+	return key, entry
+}
+
+// cacheable reports whether the request may use conditional headers
+func (c *ResponseCache) cacheable(req *http.Request) bool {
+	return req.Method == http.MethodGet
+}
+
+// addConditionalHeaders adds If-None-Match / If-Modified-Since for a previously cached response
+func (c *ResponseCache) addConditionalHeaders(req *http.Request) {
+	if !c.cacheable(req) {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, entry := c.entry(req)
+	if entry == nil {
+		return
+	}
+	if entry.etag != "" {
+		req.Header.Set(HEADER_KEY_IF_NONE_MATCH, entry.etag)
+	}
+	if entry.lastModified != "" {
+		req.Header.Set(HEADER_KEY_IF_MODIFIED_SINCE, entry.lastModified)
+	}
+}
+
+// lookup returns the cached response of the request
+func (c *ResponseCache) lookup(req *http.Request) (any, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, entry := c.entry(req)
+	if entry == nil {
+		return nil, false
+	}
+	return entry.response, true
+}
+
+// store remembers a response when the server provided validators for it
+func (c *ResponseCache) store(req *http.Request, header http.Header, response any) {
+	if !c.cacheable(req) {
+		return
+	}
+	etag, lastModified := header.Get(HEADER_KEY_ETAG), header.Get(HEADER_KEY_LAST_MODIFIED)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key, _ := c.entry(req)
+	if etag == "" && lastModified == "" {
+		delete(c.entries, key)
+		return
+	}
+	c.entries[key] = &responseCacheEntry{url: req.URL.String(), etag: etag, lastModified: lastModified, response: response}
+}
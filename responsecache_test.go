@@ -0,0 +1,114 @@
+package restapireceiver
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecuteJsonRequest_ConditionalRequests(t *testing.T) {
+	var downloads, revalidations int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/etag":
+			if r.Header.Get(HEADER_KEY_IF_NONE_MATCH) == `"v1"` {
+				revalidations++
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set(HEADER_KEY_ETAG, `"v1"`)
+		case "/modified":
+			if r.Header.Get(HEADER_KEY_IF_MODIFIED_SINCE) == "Wed, 01 May 2024 10:00:00 GMT" {
+				revalidations++
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set(HEADER_KEY_LAST_MODIFIED, "Wed, 01 May 2024 10:00:00 GMT")
+		}
+		downloads++
+		w.Write([]byte(`{"items": [1, 2, 3]}`))
+	}))
+	defer server.Close()
+
+	helper := NewHttpClientHelper()
+	helper.ResponseCache = NewResponseCache()
+	expected := map[string]interface{}{"items": []interface{}{1.0, 2.0, 3.0}}
+
+	for _, path := range []string{"/etag", "/modified", "/etag", "/modified"} {
+		req, _ := helper.NewGetRequest(server.URL + path)
+		response, err := helper.ExecuteJsonRequest(req)
+		require.NoError(t, err)
+		assert.Equal(t, expected, response)
+	}
+	assert.Equal(t, 2, downloads)
+	assert.Equal(t, 2, revalidations)
+
+	// responses without validators are not cached
+	for i := 0; i < 2; i++ {
+		req, _ := helper.NewGetRequest(server.URL + "/plain")
+		_, err := helper.ExecuteJsonRequest(req)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 4, downloads)
+	assert.Len(t, helper.ResponseCache.entries, 2)
+}
+
+func TestExecuteJsonRequest_InvalidResponseNotCached(t *testing.T) {
+	var downloads int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(HEADER_KEY_IF_NONE_MATCH) == `"maintenance"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		downloads++
+		w.Header().Set(HEADER_KEY_ETAG, `"maintenance"`)
+		w.Write([]byte(`<html><body>Down for maintenance</body></html>`))
+	}))
+	defer server.Close()
+
+	helper := NewHttpClientHelper()
+	helper.ResponseCache = NewResponseCache()
+	for i := 0; i < 2; i++ {
+		req, _ := helper.NewGetRequest(server.URL)
+		response, err := helper.ExecuteJsonRequest(req)
+		assert.ErrorContains(t, err, "failed to decode JSON response")
+		assert.Nil(t, response)
+	}
+	assert.Equal(t, 2, downloads, "the undecodable response is not revalidated")
+	assert.Empty(t, helper.ResponseCache.entries)
+}
+
+func TestResponseCache_OnlyGet(t *testing.T) {
+	cache := NewResponseCache()
+	req, _ := http.NewRequest(http.MethodPost, "http://example.com/search", nil)
+	header := http.Header{}
+	header.Set(HEADER_KEY_ETAG, `"v1"`)
+	cache.store(req, header, map[string]interface{}{})
+	assert.Empty(t, cache.entries)
+
+	cache.addConditionalHeaders(req)
+	assert.Empty(t, req.Header.Get(HEADER_KEY_IF_NONE_MATCH))
+}
+
+func TestScraper_ConditionalRequestsTemplatedQuery(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(HEADER_KEY_ETAG, `"`+r.URL.Query().Get("since")+`"`)
+		w.Write([]byte(testClusterResponse))
+	}))
+	defer server.Close()
+
+	description := Description{Endpoints: append([]EndpointDescription{}, testClusterDescription.Endpoints...)}
+	description.Endpoints[0].Query = map[string]string{"since": "{{ .Now | unixMilli }}"}
+	s := newTestScraper(t, &Config{Endpoint: server.URL, AuthToken: "testtoken", ConditionalRequests: true, Description: description})
+	for i := 0; i < 5; i++ {
+		_, err := s.scrape(context.Background())
+		require.NoError(t, err)
+		time.Sleep(time.Millisecond)
+	}
+	assert.Len(t, s.client.ResponseCache.entries, 1, "the entry of the endpoint is replaced")
+}
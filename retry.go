@@ -0,0 +1,94 @@
+package restapireceiver
+
+import (
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryConfig controls how HttpClientHelper retries transient failures.
+// Connection errors and responses with one of RetryableStatusCodes are retried with exponential
+// backoff, randomized by Jitter (0 to 1), until MaxAttempts requests were made or the scrape deadline
+// would be exceeded. A Retry-After header from the server replaces the computed backoff, up to MaxInterval.
+// The zero value makes a single attempt.
+type RetryConfig struct {
+	MaxAttempts          int           `mapstructure:"max_attempts"`
+	InitialInterval      time.Duration `mapstructure:"initial_interval"`
+	MaxInterval          time.Duration `mapstructure:"max_interval"`
+	Multiplier           float64       `mapstructure:"multiplier"`
+	Jitter               float64       `mapstructure:"jitter"`
+	RetryableStatusCodes []int         `mapstructure:"retryable_status_codes"`
+}
+
+func NewDefaultRetryConfig() RetryConfig {
+	return RetryConfig{
+		MaxAttempts:     3,
+		InitialInterval: 500 * time.Millisecond,
+		MaxInterval:     5 * time.Second,
+		Multiplier:      2,
+		Jitter:          0.2,
+		RetryableStatusCodes: []int{
+			http.StatusTooManyRequests,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+	}
+}
+
+func (r *RetryConfig) validate() []string {
+	var errs []string
+	if r.MaxAttempts < 0 {
+		errs = append(errs, "'retry.max_attempts' must not be negative")
+	}
+	if r.InitialInterval < 0 || r.MaxInterval < 0 {
+		errs = append(errs, "'retry' intervals must not be negative")
+	}
+	if r.Multiplier != 0 && r.Multiplier < 1 {
+		errs = append(errs, "'retry.multiplier' must be at least 1")
+	}
+	if r.Jitter < 0 || r.Jitter > 1 {
+		errs = append(errs, "'retry.jitter' must be between 0 and 1")
+	}
+	return errs
+}
+
+func (r *RetryConfig) isRetryableStatus(code int) bool {
+	for _, c := range r.RetryableStatusCodes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff returns how long to wait before the given retry (1 for the first retry)
+func (r *RetryConfig) backoff(retry int) time.Duration {
+	interval := float64(r.InitialInterval) * math.Pow(math.Max(r.Multiplier, 1), float64(retry-1))
+	if r.MaxInterval > 0 {
+		interval = math.Min(interval, float64(r.MaxInterval))
+	}
+	if r.Jitter > 0 {
+		interval *= 1 + r.Jitter*(2*rand.Float64()-1)
+	}
+	return time.Duration(interval)
+}
+
+// parseRetryAfter reads a Retry-After header given either in seconds or as an HTTP date
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if d := t.Sub(now); d > 0 {
+			return d, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
@@ -0,0 +1,60 @@
+package restapireceiver
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryConfig_Backoff(t *testing.T) {
+	r := RetryConfig{InitialInterval: 100 * time.Millisecond, MaxInterval: time.Second, Multiplier: 2}
+	assert.Equal(t, 100*time.Millisecond, r.backoff(1))
+	assert.Equal(t, 200*time.Millisecond, r.backoff(2))
+	assert.Equal(t, 400*time.Millisecond, r.backoff(3))
+	assert.Equal(t, time.Second, r.backoff(5))
+
+	r.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		d := r.backoff(1)
+		assert.True(t, d >= 50*time.Millisecond && d <= 150*time.Millisecond, d)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value    string
+		expected time.Duration
+		ok       bool
+	}{
+		{value: "", ok: false},
+		{value: "3", expected: 3 * time.Second, ok: true},
+		{value: now.Add(90 * time.Second).Format(http.TimeFormat), expected: 90 * time.Second, ok: true},
+		{value: now.Add(-time.Minute).Format(http.TimeFormat), expected: 0, ok: true},
+		{value: "soon", ok: false},
+	}
+
+	for _, tt := range tests {
+		d, ok := parseRetryAfter(tt.value, now)
+		assert.Equal(t, tt.ok, ok, tt.value)
+		assert.Equal(t, tt.expected, d, tt.value)
+	}
+}
+
+func TestRetryConfig_Validate(t *testing.T) {
+	r := NewDefaultRetryConfig()
+	assert.Empty(t, r.validate())
+
+	r = RetryConfig{}
+	assert.Empty(t, r.validate())
+
+	r = RetryConfig{MaxAttempts: -1, InitialInterval: -1, Multiplier: 0.5, Jitter: 2}
+	assert.Equal(t, []string{
+		"'retry.max_attempts' must not be negative",
+		"'retry' intervals must not be negative",
+		"'retry.multiplier' must be at least 1",
+		"'retry.jitter' must be between 0 and 1",
+	}, r.validate())
+}
@@ -2,44 +2,246 @@ package restapireceiver
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/config/configopaque"
 	"go.opentelemetry.io/collector/pdata/pcommon"
 	"go.opentelemetry.io/collector/pdata/pmetric"
 	"go.opentelemetry.io/collector/receiver"
+	"go.opentelemetry.io/collector/receiver/scrapererror"
 	"go.uber.org/zap"
+	"net/http"
+	"strings"
 	"time"
 )
 
 // restapiScraper handle scraping of metrics
 type restapiScraper struct {
-	client    *HttpClientHelper
-	logger    *zap.Logger
-	cfg       *Config
-	settings  receiver.CreateSettings
-	startTime pcommon.Timestamp
+	client      *HttpClientHelper
+	description *compiledDescription
+	series      *seriesCache
+	lastFetched map[*compiledEndpoint]time.Time
+	telemetry   *receiverTelemetry
+	logger      *zap.Logger
+	cfg         *Config
+	settings    receiver.CreateSettings
+	startTime   pcommon.Timestamp
+
+	tokenFile    *secretFile
+	passwordFile *secretFile
+	digest       *digestTransport
 }
 
 // newScraper creates and initializes restapiScraper
 func newScraper(logger *zap.Logger, cfg *Config, settings receiver.CreateSettings) *restapiScraper {
 	return &restapiScraper{
-		logger:   logger,
-		cfg:      cfg,
-		settings: settings,
+		logger:      logger,
+		cfg:         cfg,
+		settings:    settings,
+		series:      newSeriesCache(),
+		lastFetched: make(map[*compiledEndpoint]time.Time),
 	}
 }
 
 // start gets the Client ready
-func (s *restapiScraper) start(_ context.Context, _ component.Host) error {
-	//TODO implement start
+func (s *restapiScraper) start(_ context.Context, host component.Host) error {
+	telemetry, err := newReceiverTelemetry(s.settings.TelemetrySettings)
+	if err != nil {
+		return err
+	}
+	s.telemetry = telemetry
+
+	s.client = NewHttpClientHelper()
+	var transport http.RoundTripper = newTransport(s.cfg)
+	if s.cfg.Auth.Digest {
+		s.digest = newDigestTransport(transport)
+		transport = s.digest
+	}
+	if transport, err = s.cfg.Auth.authenticate(host, transport); err != nil {
+		return err
+	}
+	s.client.Client = &http.Client{Transport: transport}
+	s.client.Retry = s.cfg.Retry
+	// without a scrape timeout, a retry does not wait past the next scrape
+	s.client.MaxRetryDelay = s.cfg.CollectionInterval
+	s.client.RequestCompression = s.cfg.RequestCompression
+	s.client.MaxResponseSize = s.cfg.MaxResponseSize
+	if s.cfg.ConditionalRequests {
+		s.client.ResponseCache = NewResponseCache()
+	}
+	if s.cfg.RateLimit.enabled() {
+		s.client.RateLimiter = NewRateLimiter(s.cfg.RateLimit)
+	}
+	s.client.OnRetry = func(req *http.Request, attempt int, reason string) {
+		s.logger.Debug("retrying request", zap.String("url", s.client.redactUrl(req.URL)), zap.Int("attempt", attempt), zap.String("reason", reason))
+		s.telemetry.recordRetry(req.Context(), req.URL.Host, reason)
+	}
+	signer, err := s.cfg.Auth.signer()
+	if err != nil {
+		return err
+	}
+	s.client.Signer = signer
+	s.client.SensitiveQueryParams = s.cfg.Auth.sensitiveQueryParams()
+	if s.cfg.AuthTokenFile != "" {
+		s.tokenFile = newSecretFile(s.cfg.AuthTokenFile)
+	}
+	if s.cfg.PasswordFile != "" {
+		s.passwordFile = newSecretFile(s.cfg.PasswordFile)
+	}
+	if err := s.refreshCredentials(); err != nil {
+		return err
+	}
+
+	description, errs := compileDescription(&s.cfg.Description)
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid description: %s", strings.Join(errs, ", "))
+	}
+	s.description = description
+	s.startTime = pcommon.NewTimestampFromTime(time.Now())
+	return nil
+}
+
+// shutdown closes the idle connections of the client
+func (s *restapiScraper) shutdown(_ context.Context) error {
+	if s.client == nil {
+		return nil
+	}
+	if client, ok := s.client.Client.(*http.Client); ok {
+		client.CloseIdleConnections()
+	}
+	return nil
+}
+
+// scrape collects and creates OTEL metrics from the described REST API endpoints
+func (s *restapiScraper) scrape(ctx context.Context) (pmetric.Metrics, error) {
+	if len(s.description.endpoints) == 0 {
+		s.logger.Warn("no endpoints described, emitting dummy metrics")
+		return getDummyMetrics(), nil
+	}
+
+	if err := s.refreshCredentials(); err != nil {
+		s.logger.Warn("failed to reload credentials, keeping the previous ones", zap.Error(err))
+	}
+
+	builder := NewMetricsBuilder()
+	var errs scrapererror.ScrapeErrors
+	ex := &extraction{builder: builder, endpoint: s.cfg.Endpoint, now: time.Now().UTC(), startTime: s.startTime.AsTime(), series: s.series}
+	for _, ep := range s.description.endpoints {
+		if !s.isDue(ep, ex.now) {
+			continue
+		}
+		fetchCtx := ctx
+		var stats *requestStats
+		if s.cfg.RequestMetrics {
+			fetchCtx, stats = withRequestStats(ctx)
+		}
+		response, err := s.fetch(fetchCtx, ep, ex.now)
+		if stats != nil {
+			if err := s.emitRequestMetrics(builder, ep, stats); err != nil {
+				errs.AddPartial(1, fmt.Errorf("'%s': request metrics: %w", ep.Path, err))
+			}
+		}
+		if response == nil {
+			if err == nil {
+				err = errors.New("empty response")
+			}
+			errs.AddPartial(1, fmt.Errorf("failed to fetch '%s': %w", ep.Path, err))
+			continue
+		}
+		if err != nil {
+			errs.AddPartial(1, fmt.Errorf("'%s': %w", ep.Path, err))
+		}
+		s.lastFetched[ep] = ex.now
+		for _, err := range ep.extractMetrics(response, ex) {
+			errs.AddPartial(1, fmt.Errorf("'%s': %w", ep.Path, err))
+		}
+	}
+	s.series.prune(ex.now)
+	metrics := builder.GetMetrics()
+	// every resource names the target, so that request metrics can be joined with the payload metrics
+	for i := 0; i < metrics.ResourceMetrics().Len(); i++ {
+		metrics.ResourceMetrics().At(i).Resource().Attributes().PutStr(REQUEST_METRICS_ENDPOINT_ATTRIBUTE, s.cfg.Endpoint)
+	}
+	return metrics, errs.Combine()
+}
+
+// refreshCredentials sets the auth token or basic auth of the client, reading secret files again when they changed
+func (s *restapiScraper) refreshCredentials() error {
+	token, password := s.cfg.AuthToken, s.cfg.Password
+	for _, f := range []struct {
+		file  *secretFile
+		value *configopaque.String
+	}{{s.tokenFile, &token}, {s.passwordFile, &password}} {
+		if f.file == nil {
+			continue
+		}
+		value, reloaded, err := f.file.read()
+		if err != nil {
+			return fmt.Errorf("failed to read secret: %w", err)
+		}
+		if reloaded {
+			s.logger.Info("loaded credentials", zap.String("file", f.file.path))
+		}
+		*f.value = value
+	}
+	if token != "" {
+		s.client.SetAuthToken(string(token))
+	} else if s.digest != nil {
+		s.digest.setCredentials(s.cfg.Username, string(password))
+	} else if s.cfg.Username != "" {
+		s.client.SetBasicAuth(s.cfg.Username, string(password))
+	}
 	return nil
 }
 
-// scrape collects and creates OTEL metrics from a SNMP environment
-func (s *restapiScraper) scrape(_ context.Context) (pmetric.Metrics, error) {
-	s.logger.Warn("scrape() not Implemented")
-	return getDummyMetrics(), nil
-	//return pmetric.NewMetrics(), fmt.Errorf("Not implemented")
+// emitRequestMetrics adds the status, timings and sizes of the requests of an endpoint to the resource of the target
+func (s *restapiScraper) emitRequestMetrics(builder *MetricsBuilder, ep *compiledEndpoint, stats *requestStats) error {
+	rb, err := builder.GetOrCreateResource(map[string]any{REQUEST_METRICS_ENDPOINT_ATTRIBUTE: s.cfg.Endpoint}, SCOPE_NAME, SCOPE_VERSION)
+	if err != nil {
+		return err
+	}
+	method := ep.request.method
+	if ep.graphql != nil {
+		method = http.MethodPost
+	}
+	return stats.emit(rb, map[string]any{"path": ep.Path, "method": method}, time.Now().UTC())
+}
+
+// isDue reports whether the endpoint has to be fetched in this scrape. Endpoints without their own
+// interval are fetched every time; the others once their interval elapsed, allowing half a receiver
+// interval of tolerance so that scheduling jitter does not delay them by a whole tick.
+func (s *restapiScraper) isDue(ep *compiledEndpoint, now time.Time) bool {
+	if ep.CollectionInterval <= 0 {
+		return true
+	}
+	last, ok := s.lastFetched[ep]
+	if !ok {
+		return true
+	}
+	return now.Sub(last) >= ep.CollectionInterval-s.cfg.CollectionInterval/2
+}
+
+// fetch executes the request of a described endpoint and returns the decoded response.
+// GraphQL endpoints may return data together with the errors reported by the server.
+func (s *restapiScraper) fetch(ctx context.Context, ep *compiledEndpoint, now time.Time) (any, error) {
+	if ep.graphql != nil {
+		return ep.graphql.execute(ctx, s.client, BuildUrl(s.cfg.Endpoint, ep.Path))
+	}
+	data := &requestTemplateData{Now: now, LastFetched: s.lastFetched[ep], Interval: ep.CollectionInterval}
+	if data.Interval <= 0 {
+		data.Interval = s.cfg.CollectionInterval
+	}
+	req, err := ep.newRequest(s.client, s.cfg.Endpoint, data)
+	if err != nil {
+		return nil, err
+	}
+	// one cache entry per endpoint, whose query may be templated with the time
+	response, err := s.client.executeJson(req.WithContext(withResponseCacheKey(ctx, ep)))
+	if err != nil {
+		return nil, err
+	}
+	return response, nil
 }
 
 func getDummyMetrics() pmetric.Metrics {
@@ -0,0 +1,139 @@
+package restapireceiver
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/receiver/receivertest"
+	"go.opentelemetry.io/collector/receiver/scrapererror"
+	"go.uber.org/zap"
+)
+
+// newTestScraper creates and starts a scraper for the given config
+func newTestScraper(t *testing.T, cfg *Config) *restapiScraper {
+	s := newScraper(zap.NewNop(), cfg, receivertest.NewNopCreateSettings())
+	require.NoError(t, s.start(context.Background(), componenttest.NewNopHost()))
+	return s
+}
+
+func TestScraper_Scrape(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/cluster", r.URL.Path)
+		assert.Equal(t, "testtoken", r.Header.Get(HEADER_KEY_AUTHORIZATION))
+		w.Write([]byte(testClusterResponse))
+	}))
+	defer server.Close()
+
+	s := newTestScraper(t, &Config{Endpoint: server.URL, AuthToken: "testtoken", Description: testClusterDescription})
+	metrics, err := s.scrape(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 3, metrics.ResourceMetrics().Len())
+	assert.Equal(t, 9, metrics.MetricCount())
+}
+
+func TestScraper_ScrapeEndpointIntervals(t *testing.T) {
+	calls := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls[r.URL.Path]++
+		w.Write([]byte(`{"name": "x", "value": 1}`))
+	}))
+	defer server.Close()
+
+	resources := []ResourceDescription{{
+		Attributes: map[string]string{"name": "name"},
+		Metrics:    []MetricDescription{{Name: "value", Field: "value"}},
+	}}
+	cfg := &Config{Endpoint: server.URL, AuthToken: "testtoken", Description: Description{Endpoints: []EndpointDescription{
+		{Path: "/perf", Resources: resources},
+		{Path: "/inventory", CollectionInterval: time.Hour, Resources: resources},
+	}}}
+	cfg.CollectionInterval = 10 * time.Second
+	s := newTestScraper(t, cfg)
+
+	for i := 0; i < 3; i++ {
+		_, err := s.scrape(context.Background())
+		require.NoError(t, err)
+	}
+	assert.Equal(t, map[string]int{"/perf": 3, "/inventory": 1}, calls)
+
+	// an hour later, within the tolerance of half a receiver interval
+	inventory := s.description.endpoints[1]
+	s.lastFetched[inventory] = s.lastFetched[inventory].Add(-time.Hour + 4*time.Second)
+	_, err := s.scrape(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"/perf": 4, "/inventory": 2}, calls)
+}
+
+func TestScraper_ScrapePostEndpoint(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, CONTENT_TYPE_JSON, r.Header.Get(HEADER_KEY_CONTENT_TYPE))
+		body, _ := io.ReadAll(r.Body)
+		assert.Equal(t, `{"type": "volume"}`, string(body))
+		w.Write([]byte(`{"results": [{"name": "vol1", "size": 10}]}`))
+	}))
+	defer server.Close()
+
+	s := newTestScraper(t, &Config{Endpoint: server.URL, AuthToken: "testtoken", Description: Description{Endpoints: []EndpointDescription{{
+		Path:   "/api/search",
+		Method: http.MethodPost,
+		Body:   `{"type": "volume"}`,
+		Resources: []ResourceDescription{{
+			Selector:   "results[*]",
+			Attributes: map[string]string{"volume": "name"},
+			Metrics:    []MetricDescription{{Name: "size", Field: "size"}},
+		}},
+	}}}})
+	metrics, err := s.scrape(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, metrics.MetricCount())
+}
+
+func TestScraper_ScrapeGraphQLEndpoint(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		w.Write([]byte(`{"data": {"volumes": [{"name": "vol1", "size": 10}]}, "errors": [{"message": "partial result"}]}`))
+	}))
+	defer server.Close()
+
+	cfg := &Config{Endpoint: server.URL, AuthToken: "testtoken", Description: Description{Endpoints: []EndpointDescription{{
+		Path:    "/graphql",
+		GraphQL: &GraphQLDescription{Query: "{ volumes { name size } }"},
+		Resources: []ResourceDescription{{
+			Selector:   "volumes[*]",
+			Attributes: map[string]string{"name": "name"},
+			Metrics:    []MetricDescription{{Name: "size", Field: "size"}},
+		}},
+	}}}}
+	s := newTestScraper(t, cfg)
+	metrics, err := s.scrape(context.Background())
+	assert.EqualError(t, err, "'/graphql': graphql error: partial result")
+	assert.True(t, scrapererror.IsPartialScrapeError(err))
+	assert.Equal(t, 1, metrics.MetricCount())
+}
+
+func TestScraper_ScrapeFailedEndpoint(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	endpoint := server.URL
+	server.Close()
+
+	s := newTestScraper(t, &Config{Endpoint: endpoint, AuthToken: "testtoken", Description: testClusterDescription})
+	_, err := s.scrape(context.Background())
+	require.Error(t, err)
+	assert.True(t, scrapererror.IsPartialScrapeError(err))
+}
+
+func TestScraper_StartInvalidDescription(t *testing.T) {
+	cfg := &Config{Endpoint: "localhost", AuthToken: "testtoken", Description: Description{
+		Endpoints: []EndpointDescription{{Resources: []ResourceDescription{{Selector: "["}}}},
+	}}
+	s := newScraper(zap.NewNop(), cfg, receivertest.NewNopCreateSettings())
+	assert.Error(t, s.start(context.Background(), componenttest.NewNopHost()))
+}
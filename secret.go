@@ -0,0 +1,51 @@
+package restapireceiver
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/collector/config/configopaque"
+)
+
+// REDACTED replaces secrets in logs and errors, as configopaque.String does in configs
+const REDACTED = "[REDACTED]"
+
+// secretFile reads a secret from a file, such as a mounted Kubernetes secret, and reads it again
+// whenever the file changes so that rotated credentials apply without a restart
+type secretFile struct {
+	path    string
+	mu      sync.Mutex
+	modTime time.Time
+	size    int64
+	value   configopaque.String
+}
+
+func newSecretFile(path string) *secretFile {
+	return &secretFile{path: path}
+}
+
+// read returns the current secret, and whether it was (re)loaded from the file
+func (f *secretFile) read() (configopaque.String, bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return f.value, false, err
+	}
+	if info.ModTime().Equal(f.modTime) && info.Size() == f.size {
+		return f.value, false, nil
+	}
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return f.value, false, err
+	}
+	value := strings.TrimRight(string(data), "\r\n")
+	if value == "" {
+		return f.value, false, fmt.Errorf("secret file '%s' is empty", f.path)
+	}
+	f.value, f.modTime, f.size = configopaque.String(value), info.ModTime(), info.Size()
+	return f.value, true, nil
+}
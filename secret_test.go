@@ -0,0 +1,101 @@
+package restapireceiver
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/config/configopaque"
+	"go.opentelemetry.io/collector/confmap"
+	"go.opentelemetry.io/collector/receiver/receivertest"
+	"go.uber.org/zap"
+)
+
+func TestSecret_Redacted(t *testing.T) {
+	cfg := Config{AuthToken: "s3cr3t", Password: "hunter2"}
+	assert.NotContains(t, fmt.Sprintf("%v %+v %#v", cfg, cfg, cfg), "s3cr3t")
+	assert.NotContains(t, fmt.Sprintf("%v %+v %#v", cfg, cfg, cfg), "hunter2")
+
+	conf := confmap.New()
+	require.NoError(t, conf.Marshal(cfg))
+	assert.Equal(t, REDACTED, conf.Get("auth_token"))
+}
+
+func TestSecret_Unmarshal(t *testing.T) {
+	cfg := Config{}
+	require.NoError(t, confmap.NewFromStringMap(map[string]any{"auth_token": "s3cr3t"}).Unmarshal(&cfg))
+	assert.Equal(t, configopaque.String("s3cr3t"), cfg.AuthToken)
+}
+
+func writeSecret(t *testing.T, path, value string, modTime time.Time) {
+	require.NoError(t, os.WriteFile(path, []byte(value), 0600))
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+}
+
+func TestSecretFile_Read(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	t0 := time.Now().Add(-time.Hour)
+	writeSecret(t, path, "first\n", t0)
+
+	f := newSecretFile(path)
+	value, reloaded, err := f.read()
+	require.NoError(t, err)
+	assert.True(t, reloaded)
+	assert.Equal(t, configopaque.String("first"), value)
+
+	_, reloaded, err = f.read()
+	require.NoError(t, err)
+	assert.False(t, reloaded)
+
+	writeSecret(t, path, "second", t0.Add(time.Minute))
+	value, reloaded, err = f.read()
+	require.NoError(t, err)
+	assert.True(t, reloaded)
+	assert.Equal(t, configopaque.String("second"), value)
+
+	// a failed read keeps the previous secret
+	writeSecret(t, path, "", t0.Add(2*time.Minute))
+	value, _, err = f.read()
+	assert.Error(t, err)
+	assert.Equal(t, configopaque.String("second"), value)
+}
+
+func TestScraper_RotatedTokenFile(t *testing.T) {
+	var tokens []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokens = append(tokens, r.Header.Get(HEADER_KEY_AUTHORIZATION))
+		w.Write([]byte(testClusterResponse))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "token")
+	t0 := time.Now().Add(-time.Hour)
+	writeSecret(t, path, "old-token\n", t0)
+	s := newTestScraper(t, &Config{Endpoint: server.URL, AuthTokenFile: path, Description: testClusterDescription})
+	_, err := s.scrape(context.Background())
+	require.NoError(t, err)
+
+	writeSecret(t, path, "new-token\n", t0.Add(time.Minute))
+	_, err = s.scrape(context.Background())
+	require.NoError(t, err)
+
+	// the previous token is kept when the file cannot be read
+	require.NoError(t, os.Remove(path))
+	_, err = s.scrape(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"old-token", "new-token", "new-token"}, tokens)
+}
+
+func TestScraper_StartMissingPasswordFile(t *testing.T) {
+	cfg := &Config{Endpoint: "localhost", Username: "user", PasswordFile: "/nonexistent/password"}
+	s := newScraper(zap.NewNop(), cfg, receivertest.NewNopCreateSettings())
+	assert.ErrorContains(t, s.start(context.Background(), componenttest.NewNopHost()), "failed to read secret")
+}
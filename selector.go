@@ -0,0 +1,159 @@
+package restapireceiver
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// selector addresses values inside a decoded JSON document.
+// Syntax: `a.b.c`, `items[0].name`, `nodes[*].capacity`. A leading `$.` (or a bare `$`)
+// makes the path relative to the document root instead of the current item.
+type selector struct {
+	fromRoot bool
+	segments []selectorSegment
+}
+
+type selectorSegment struct {
+	field    string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+func parseSelector(path string) (selector, error) {
+	sel := selector{}
+	path = strings.TrimSpace(path)
+	if path == "$" {
+		return selector{fromRoot: true}, nil
+	}
+	if strings.HasPrefix(path, "$.") {
+		sel.fromRoot = true
+		path = path[2:]
+	}
+	if path == "" {
+		return sel, nil
+	}
+	for _, part := range strings.Split(path, ".") {
+		name := part
+		brackets := ""
+		if i := strings.Index(part, "["); i >= 0 {
+			name, brackets = part[:i], part[i:]
+		}
+		if name != "" {
+			sel.segments = append(sel.segments, selectorSegment{field: name})
+		} else if brackets == "" {
+			return sel, fmt.Errorf("empty segment in selector '%s'", path)
+		}
+		for brackets != "" {
+			end := strings.Index(brackets, "]")
+			if brackets[0] != '[' || end < 0 {
+				return sel, fmt.Errorf("malformed index in selector '%s'", path)
+			}
+			idx := brackets[1:end]
+			brackets = brackets[end+1:]
+			if idx == "*" {
+				sel.segments = append(sel.segments, selectorSegment{wildcard: true})
+				continue
+			}
+			n, err := strconv.Atoi(idx)
+			if err != nil || n < 0 {
+				return sel, fmt.Errorf("invalid index '%s' in selector '%s'", idx, path)
+			}
+			sel.segments = append(sel.segments, selectorSegment{index: n, isIndex: true})
+		}
+	}
+	return sel, nil
+}
+
+// isMulti reports whether the selector may yield more than one value
+func (s selector) isMulti() bool {
+	for _, seg := range s.segments {
+		if seg.wildcard {
+			return true
+		}
+	}
+	return false
+}
+
+// Select returns all values matched by the selector. Missing fields yield no values.
+func (s selector) Select(root, current any) []any {
+	start := current
+	if s.fromRoot {
+		start = root
+	}
+	values := []any{start}
+	for _, seg := range s.segments {
+		next := []any{}
+		for _, v := range values {
+			switch {
+			case seg.wildcard:
+				switch t := v.(type) {
+				case []any:
+					next = append(next, t...)
+				case map[string]any:
+					for _, k := range sortedKeys(t) {
+						next = append(next, t[k])
+					}
+				}
+			case seg.isIndex:
+				if arr, ok := v.([]any); ok && seg.index < len(arr) {
+					next = append(next, arr[seg.index])
+				}
+			default:
+				if obj, ok := v.(map[string]any); ok {
+					if fv, ok := obj[seg.field]; ok {
+						next = append(next, fv)
+					}
+				}
+			}
+		}
+		values = next
+	}
+	return values
+}
+
+// SelectOne returns the first value matched by the selector
+func (s selector) SelectOne(root, current any) (any, bool) {
+	values := s.Select(root, current)
+	if len(values) == 0 {
+		return nil, false
+	}
+	return values[0], true
+}
+
+// toFloat converts a decoded JSON value into a number
+func toFloat(v any) (float64, error) {
+	switch t := v.(type) {
+	case float64:
+		return t, nil
+	case int64:
+		return float64(t), nil
+	case int:
+		return float64(t), nil
+	case bool:
+		if t {
+			return 1, nil
+		}
+		return 0, nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(t), 64)
+		if err != nil {
+			return 0, fmt.Errorf("value '%s' is not numeric", t)
+		}
+		return f, nil
+	case nil:
+		return 0, fmt.Errorf("value is null")
+	}
+	return 0, fmt.Errorf("value of type %T is not numeric", v)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
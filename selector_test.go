@@ -0,0 +1,76 @@
+package restapireceiver
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelector_Select(t *testing.T) {
+	var doc any
+	err := json.Unmarshal([]byte(`{
+		"cluster": {"name": "cluster1"},
+		"nodes": [
+			{"name": "node1", "capacity": 2048},
+			{"name": "node2", "capacity": 1024}
+		]
+	}`), &doc)
+	assert.NoError(t, err)
+	node := doc.(map[string]any)["nodes"].([]any)[1]
+
+	tests := []struct {
+		name     string
+		path     string
+		current  any
+		expected []any
+	}{
+		{name: "Empty", path: "", current: doc, expected: []any{doc}},
+		{name: "Field", path: "cluster.name", current: doc, expected: []any{"cluster1"}},
+		{name: "Index", path: "nodes[0].name", current: doc, expected: []any{"node1"}},
+		{name: "Wildcard", path: "nodes[*].capacity", current: doc, expected: []any{2048.0, 1024.0}},
+		{name: "Relative", path: "name", current: node, expected: []any{"node2"}},
+		{name: "Root", path: "$.cluster.name", current: node, expected: []any{"cluster1"}},
+		{name: "Missing", path: "nodes[5].name", current: doc, expected: []any{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sel, err := parseSelector(tt.path)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, sel.Select(doc, tt.current))
+		})
+	}
+}
+
+func TestParseSelector_Invalid(t *testing.T) {
+	for _, path := range []string{"a..b", "nodes[x]", "nodes[1", "nodes[-1]"} {
+		_, err := parseSelector(path)
+		assert.Error(t, err, path)
+	}
+}
+
+func TestToFloat(t *testing.T) {
+	tests := []struct {
+		value    any
+		expected float64
+		wantErr  bool
+	}{
+		{value: 1.5, expected: 1.5},
+		{value: " 42 ", expected: 42},
+		{value: true, expected: 1},
+		{value: "abc", wantErr: true},
+		{value: nil, wantErr: true},
+		{value: map[string]any{}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		v, err := toFloat(tt.value)
+		if tt.wantErr {
+			assert.Error(t, err)
+		} else {
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, v)
+		}
+	}
+}
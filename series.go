@@ -0,0 +1,157 @@
+package restapireceiver
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// seriesCacheExpiry is how long a series is remembered after it was last seen in a response
+const seriesCacheExpiry = 24 * time.Hour
+
+// SeriesDescription expands an array of samples, such as `[[1714557600, 12.5], [1714557610, 13.1]]`,
+// into one datapoint per sample. Value and Timestamp.Field are selectors relative to each sample and
+// default to `[1]` and `[0]`; Timestamp.Format defaults to epoch_s.
+type SeriesDescription struct {
+	Field     string               `mapstructure:"field"`
+	Value     string               `mapstructure:"value"`
+	Timestamp TimestampDescription `mapstructure:"timestamp"`
+}
+
+type compiledSeries struct {
+	field     selector
+	value     selector
+	timestamp *compiledTimestamp
+}
+
+func compileSeries(sd *SeriesDescription) (*compiledSeries, []string) {
+	var errs []string
+	c := &compiledSeries{}
+	var err error
+	if sd.Field == "" {
+		errs = append(errs, "'field' is required")
+	} else if c.field, err = parseSelector(sd.Field); err != nil {
+		errs = append(errs, err.Error())
+	}
+	value := sd.Value
+	if value == "" {
+		value = "[1]"
+	}
+	if c.value, err = parseSelector(value); err != nil {
+		errs = append(errs, err.Error())
+	}
+	td := sd.Timestamp
+	if td.Field == "" {
+		td.Field = "[0]"
+	}
+	if td.Format == "" {
+		td.Format = TIME_FORMAT_EPOCH_S
+	}
+	var tsErrs []string
+	c.timestamp, tsErrs = compileTimestamp(&td)
+	for _, e := range tsErrs {
+		errs = append(errs, "timestamp: "+e)
+	}
+	return c, errs
+}
+
+// samples reads the series of the given object ordered by time, with values converted by the metric
+func (cs *compiledSeries) samples(cm *compiledMetric, root, item any) ([]DataPoint, error) {
+	var points []DataPoint
+	var skipped int
+	var firstErr error
+	// a wildcard selects the samples themselves, otherwise the field is the array of samples
+	samples := cs.field.Select(root, item)
+	if !cs.field.isMulti() {
+		if len(samples) == 0 {
+			return nil, fmt.Errorf("series not found")
+		}
+		arr, ok := samples[0].([]any)
+		if !ok {
+			return nil, fmt.Errorf("series is not an array")
+		}
+		samples = arr
+	}
+	for _, s := range samples {
+		p, err := cs.sample(cm, root, s)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			skipped++
+			continue
+		}
+		points = append(points, p)
+	}
+	sort.SliceStable(points, func(i, j int) bool {
+		return points[i].Timestamp.Before(points[j].Timestamp)
+	})
+	if skipped > 0 {
+		return points, fmt.Errorf("skipped %d invalid samples: %w", skipped, firstErr)
+	}
+	return points, nil
+}
+
+func (cs *compiledSeries) sample(cm *compiledMetric, root, sample any) (DataPoint, error) {
+	ts, err := cs.timestamp.resolve(root, sample)
+	if err != nil {
+		return DataPoint{}, err
+	}
+	raw, ok := cs.value.SelectOne(root, sample)
+	if !ok {
+		return DataPoint{}, fmt.Errorf("sample value not found")
+	}
+	v, err := cm.fieldValue(raw)
+	if err != nil {
+		return DataPoint{}, err
+	}
+	return DataPoint{Timestamp: ts, Value: v}, nil
+}
+
+// seriesCache remembers the last emitted sample time of each series across scrapes
+type seriesCache struct {
+	mu      sync.Mutex
+	entries map[string]*seriesCacheEntry
+}
+
+type seriesCacheEntry struct {
+	lastTimestamp time.Time
+	lastSeen      time.Time
+}
+
+func newSeriesCache() *seriesCache {
+	return &seriesCache{entries: make(map[string]*seriesCacheEntry)}
+}
+
+// filterNew drops the samples already emitted for the series and remembers the newest remaining one.
+// Samples must be ordered by time.
+func (c *seriesCache) filterNew(key string, points []DataPoint, now time.Time) []DataPoint {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok {
+		entry = &seriesCacheEntry{}
+		c.entries[key] = entry
+	}
+	entry.lastSeen = now
+	fresh := make([]DataPoint, 0, len(points))
+	for _, p := range points {
+		if p.Timestamp.After(entry.lastTimestamp) {
+			fresh = append(fresh, p)
+			entry.lastTimestamp = p.Timestamp
+		}
+	}
+	return fresh
+}
+
+// prune forgets series that have not been seen for seriesCacheExpiry
+func (c *seriesCache) prune(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, entry := range c.entries {
+		if now.Sub(entry.lastSeen) > seriesCacheExpiry {
+			delete(c.entries, key)
+		}
+	}
+}
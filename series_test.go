@@ -0,0 +1,57 @@
+package restapireceiver
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeries_Samples(t *testing.T) {
+	var doc any
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"iops": [[1714557620, 30], [1714557600, 10], [1714557610, "bad"]],
+		"latency": [{"t": "2024-05-01T10:00:00Z", "v": "5ms"}, {"t": "2024-05-01T10:00:10Z", "v": "7ms"}]
+	}`), &doc))
+
+	cs, errs := compileSeries(&SeriesDescription{Field: "iops"})
+	require.Empty(t, errs)
+	points, err := cs.samples(&compiledMetric{}, doc, doc)
+	assert.EqualError(t, err, "skipped 1 invalid samples: invalid quantity 'bad'")
+	assert.Equal(t, []DataPoint{
+		{Timestamp: time.Unix(1714557600, 0).UTC(), Value: 10},
+		{Timestamp: time.Unix(1714557620, 0).UTC(), Value: 30},
+	}, points)
+
+	cs, errs = compileSeries(&SeriesDescription{Field: "latency[*]", Value: "v", Timestamp: TimestampDescription{Field: "t", Format: TIME_FORMAT_RFC3339}})
+	require.Empty(t, errs)
+	points, err = cs.samples(&compiledMetric{MetricDescription: MetricDescription{Unit: "s"}}, doc, doc)
+	assert.NoError(t, err)
+	require.Len(t, points, 2)
+	assert.Equal(t, 0.007, points[1].Value)
+	assert.Equal(t, time.Date(2024, 5, 1, 10, 0, 10, 0, time.UTC), points[1].Timestamp)
+
+	cs, errs = compileSeries(&SeriesDescription{Field: "missing"})
+	require.Empty(t, errs)
+	_, err = cs.samples(&compiledMetric{}, doc, doc)
+	assert.EqualError(t, err, "series not found")
+}
+
+func TestSeriesCache(t *testing.T) {
+	cache := newSeriesCache()
+	t0 := time.Unix(1714557600, 0)
+	now := time.Now()
+	points := []DataPoint{{Timestamp: t0, Value: 1}, {Timestamp: t0.Add(10 * time.Second), Value: 2}}
+
+	assert.Equal(t, points, cache.filterNew("a", points, now))
+	assert.Empty(t, cache.filterNew("a", points, now))
+	assert.Equal(t, points, cache.filterNew("b", points, now))
+
+	next := append(points, DataPoint{Timestamp: t0.Add(20 * time.Second), Value: 3})
+	assert.Equal(t, next[2:], cache.filterNew("a", next, now))
+
+	cache.prune(now.Add(seriesCacheExpiry + time.Second))
+	assert.Empty(t, cache.entries)
+}
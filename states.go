@@ -0,0 +1,131 @@
+package restapireceiver
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	STATE_MODE_LOOKUP    = "lookup"
+	STATE_MODE_STATE_SET = "state_set"
+
+	UNKNOWN_STATE_ERROR  = "error"
+	UNKNOWN_STATE_IGNORE = "ignore"
+	UNKNOWN_STATE_REPORT = "report"
+
+	DEFAULT_STATE_ATTRIBUTE = "state"
+	UNKNOWN_STATE_NAME      = "unknown"
+)
+
+// StateMapping turns string fields such as `"status": "degraded"` into numbers.
+//
+// In lookup mode the value is looked up in Values and recorded as a single datapoint.
+// In state_set mode one datapoint is recorded per entry of States, 1 for the current state
+// and 0 for the others, distinguished by the Attribute datapoint attribute.
+//
+// Unknown decides what happens to values that are not listed: "error" (default) reports a scrape error,
+// "ignore" records nothing, and "report" records UnknownValue (lookup) or an extra "unknown" state (state_set).
+type StateMapping struct {
+	Mode            string             `mapstructure:"mode"`
+	Values          map[string]float64 `mapstructure:"values"`
+	States          []string           `mapstructure:"states"`
+	Attribute       string             `mapstructure:"attribute"`
+	CaseInsensitive bool               `mapstructure:"case_insensitive"`
+	Unknown         string             `mapstructure:"unknown"`
+	UnknownValue    float64            `mapstructure:"unknown_value"`
+}
+
+type compiledStateMapping struct {
+	StateMapping
+	lookup map[string]float64 // normalized value to number
+	names  map[string]string  // normalized value to state name
+	states []string           // datapoints emitted in state_set mode
+}
+
+// errUnknownStateIgnored signals that an unknown state must not be recorded
+var errUnknownStateIgnored = errors.New("unknown state ignored")
+
+func compileStateMapping(sm *StateMapping) (*compiledStateMapping, []string) {
+	var errs []string
+	c := &compiledStateMapping{
+		StateMapping: *sm,
+		lookup:       make(map[string]float64),
+		names:        make(map[string]string),
+	}
+	if c.Attribute == "" {
+		c.Attribute = DEFAULT_STATE_ATTRIBUTE
+	}
+	if c.Unknown == "" {
+		c.Unknown = UNKNOWN_STATE_ERROR
+	}
+	switch c.Unknown {
+	case UNKNOWN_STATE_ERROR, UNKNOWN_STATE_IGNORE, UNKNOWN_STATE_REPORT:
+	default:
+		errs = append(errs, fmt.Sprintf("unknown must be one of '%s', '%s' or '%s'", UNKNOWN_STATE_ERROR, UNKNOWN_STATE_IGNORE, UNKNOWN_STATE_REPORT))
+	}
+
+	switch c.Mode {
+	case "", STATE_MODE_LOOKUP:
+		c.Mode = STATE_MODE_LOOKUP
+		if len(c.Values) == 0 {
+			errs = append(errs, "'values' is required in lookup mode")
+		}
+		for k, v := range c.Values {
+			c.lookup[c.normalize(k)] = v
+		}
+	case STATE_MODE_STATE_SET:
+		if len(c.States) == 0 {
+			errs = append(errs, "'states' is required in state_set mode")
+		}
+		for _, s := range c.States {
+			if _, ok := c.names[c.normalize(s)]; ok {
+				errs = append(errs, fmt.Sprintf("duplicate state '%s'", s))
+			}
+			c.names[c.normalize(s)] = s
+			c.states = append(c.states, s)
+		}
+		sort.Strings(c.states)
+		if c.Unknown == UNKNOWN_STATE_REPORT {
+			c.states = append(c.states, UNKNOWN_STATE_NAME)
+		}
+	default:
+		errs = append(errs, fmt.Sprintf("unknown state mode '%s'", c.Mode))
+	}
+	return c, errs
+}
+
+func (c *compiledStateMapping) normalize(s string) string {
+	if c.CaseInsensitive {
+		return strings.ToLower(s)
+	}
+	return s
+}
+
+// lookupValue returns the number mapped to a raw field value in lookup mode
+func (c *compiledStateMapping) lookupValue(raw any) (float64, error) {
+	key := c.normalize(fmt.Sprint(raw))
+	if v, ok := c.lookup[key]; ok {
+		return v, nil
+	}
+	return c.UnknownValue, c.unknownError(raw)
+}
+
+// currentState returns the state name of a raw field value in state_set mode
+func (c *compiledStateMapping) currentState(raw any) (string, error) {
+	if name, ok := c.names[c.normalize(fmt.Sprint(raw))]; ok {
+		return name, nil
+	}
+	return UNKNOWN_STATE_NAME, c.unknownError(raw)
+}
+
+func (c *compiledStateMapping) unknownError(raw any) error {
+	switch c.Unknown {
+	case UNKNOWN_STATE_REPORT:
+		return nil
+	case UNKNOWN_STATE_IGNORE:
+		return errUnknownStateIgnored
+	}
+	return fmt.Errorf("unknown state '%v'", raw)
+}
@@ -0,0 +1,104 @@
+package restapireceiver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStateMapping_Lookup(t *testing.T) {
+	tests := []struct {
+		name     string
+		mapping  StateMapping
+		raw      any
+		expected float64
+		err      error
+		errMsg   string
+	}{
+		{
+			name:     "Known",
+			mapping:  StateMapping{Values: map[string]float64{"GREEN": 0, "YELLOW": 1, "RED": 2}},
+			raw:      "YELLOW",
+			expected: 1,
+		},
+		{
+			name:     "CaseInsensitive",
+			mapping:  StateMapping{Values: map[string]float64{"GREEN": 0, "RED": 2}, CaseInsensitive: true},
+			raw:      "red",
+			expected: 2,
+		},
+		{
+			name:     "NonString",
+			mapping:  StateMapping{Values: map[string]float64{"true": 1, "false": 0}},
+			raw:      true,
+			expected: 1,
+		},
+		{
+			name:    "UnknownError",
+			mapping: StateMapping{Values: map[string]float64{"GREEN": 0}},
+			raw:     "BLUE",
+			errMsg:  "unknown state 'BLUE'",
+		},
+		{
+			name:    "UnknownIgnore",
+			mapping: StateMapping{Values: map[string]float64{"GREEN": 0}, Unknown: UNKNOWN_STATE_IGNORE},
+			raw:     "BLUE",
+			err:     errUnknownStateIgnored,
+		},
+		{
+			name:     "UnknownReport",
+			mapping:  StateMapping{Values: map[string]float64{"GREEN": 0}, Unknown: UNKNOWN_STATE_REPORT, UnknownValue: -1},
+			raw:      "BLUE",
+			expected: -1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, errs := compileStateMapping(&tt.mapping)
+			require.Empty(t, errs)
+			v, err := c.lookupValue(tt.raw)
+			switch {
+			case tt.err != nil:
+				assert.Equal(t, tt.err, err)
+			case tt.errMsg != "":
+				assert.EqualError(t, err, tt.errMsg)
+			default:
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expected, v)
+			}
+		})
+	}
+}
+
+func TestStateMapping_StateSet(t *testing.T) {
+	c, errs := compileStateMapping(&StateMapping{
+		Mode:            STATE_MODE_STATE_SET,
+		States:          []string{"ok", "degraded", "failed"},
+		CaseInsensitive: true,
+		Unknown:         UNKNOWN_STATE_REPORT,
+	})
+	require.Empty(t, errs)
+	assert.Equal(t, []string{"degraded", "failed", "ok", UNKNOWN_STATE_NAME}, c.states)
+	assert.Equal(t, DEFAULT_STATE_ATTRIBUTE, c.Attribute)
+
+	state, err := c.currentState("Degraded")
+	assert.NoError(t, err)
+	assert.Equal(t, "degraded", state)
+
+	state, err = c.currentState("rebooting")
+	assert.NoError(t, err)
+	assert.Equal(t, UNKNOWN_STATE_NAME, state)
+}
+
+func TestCompileStateMapping_Errors(t *testing.T) {
+	_, errs := compileStateMapping(&StateMapping{Mode: "bitmap", Unknown: "panic"})
+	assert.Equal(t, []string{"unknown must be one of 'error', 'ignore' or 'report'", "unknown state mode 'bitmap'"}, errs)
+
+	_, errs = compileStateMapping(&StateMapping{})
+	assert.Equal(t, []string{"'values' is required in lookup mode"}, errs)
+
+	_, errs = compileStateMapping(&StateMapping{Mode: STATE_MODE_STATE_SET, States: []string{"a", "A"}, CaseInsensitive: true})
+	assert.Equal(t, []string{"duplicate state 'A'"}, errs)
+}
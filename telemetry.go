@@ -0,0 +1,35 @@
+package restapireceiver
+
+import (
+	"context"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/otel/attribute"
+	otelmetric "go.opentelemetry.io/otel/metric"
+)
+
+// receiverTelemetry holds the self-telemetry instruments of the receiver
+type receiverTelemetry struct {
+	requestRetries otelmetric.Int64Counter
+}
+
+func newReceiverTelemetry(settings component.TelemetrySettings) (*receiverTelemetry, error) {
+	meter := settings.MeterProvider.Meter(SCOPE_NAME)
+	requestRetries, err := meter.Int64Counter(
+		"restapi_request_retries",
+		otelmetric.WithDescription("Number of REST API requests retried after a transient failure"),
+		otelmetric.WithUnit("{retries}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &receiverTelemetry{requestRetries: requestRetries}, nil
+}
+
+// recordRetry counts a retry, reason is the retried status code or "error" for connection failures
+func (t *receiverTelemetry) recordRetry(ctx context.Context, host, reason string) {
+	t.requestRetries.Add(ctx, 1, otelmetric.WithAttributes(
+		attribute.String("host", host),
+		attribute.String("reason", reason),
+	))
+}
@@ -0,0 +1,120 @@
+package restapireceiver
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	TIME_FORMAT_RFC3339  = "rfc3339"
+	TIME_FORMAT_EPOCH_S  = "epoch_s"
+	TIME_FORMAT_EPOCH_MS = "epoch_ms"
+	TIME_FORMAT_EPOCH_US = "epoch_us"
+	TIME_FORMAT_EPOCH_NS = "epoch_ns"
+)
+
+// TimestampDescription tells where a time is found in the response and how it is formatted.
+// Format is one of rfc3339 (default), epoch_s, epoch_ms, epoch_us, epoch_ns or a Go time layout
+// such as `2006-01-02 15:04:05`. Timezone is the IANA location used by layouts without a zone (default UTC).
+type TimestampDescription struct {
+	Field    string `mapstructure:"field"`
+	Format   string `mapstructure:"format"`
+	Timezone string `mapstructure:"timezone"`
+}
+
+type compiledTimestamp struct {
+	TimestampDescription
+	field    selector
+	location *time.Location
+}
+
+func compileTimestamp(td *TimestampDescription) (*compiledTimestamp, []string) {
+	var errs []string
+	c := &compiledTimestamp{TimestampDescription: *td, location: time.UTC}
+	if c.Format == "" {
+		c.Format = TIME_FORMAT_RFC3339
+	}
+	var err error
+	if td.Field == "" {
+		errs = append(errs, "'field' is required")
+	} else if c.field, err = parseSelector(td.Field); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if td.Timezone != "" {
+		if c.location, err = time.LoadLocation(td.Timezone); err != nil {
+			errs = append(errs, fmt.Sprintf("invalid timezone '%s'", td.Timezone))
+		}
+	}
+	return c, errs
+}
+
+// resolve reads the time from the given object
+func (c *compiledTimestamp) resolve(root, item any) (time.Time, error) {
+	raw, ok := c.field.SelectOne(root, item)
+	if !ok || raw == nil {
+		return time.Time{}, fmt.Errorf("timestamp field '%s' not found", c.Field)
+	}
+	return c.parse(raw)
+}
+
+// parse converts a raw value to a time according to the format
+func (c *compiledTimestamp) parse(raw any) (time.Time, error) {
+	var unit time.Duration
+	switch c.Format {
+	case TIME_FORMAT_EPOCH_S:
+		unit = time.Second
+	case TIME_FORMAT_EPOCH_MS:
+		unit = time.Millisecond
+	case TIME_FORMAT_EPOCH_US:
+		unit = time.Microsecond
+	case TIME_FORMAT_EPOCH_NS:
+		unit = time.Nanosecond
+	}
+	if unit != 0 {
+		if str, ok := raw.(string); ok {
+			if n, err := strconv.ParseInt(strings.TrimSpace(str), 10, 64); err == nil {
+				return time.Unix(0, n*int64(unit)).UTC(), nil
+			}
+		}
+		v, err := toFloat(raw)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid %s timestamp: %w", c.Format, err)
+		}
+		sec, frac := math.Modf(v * float64(unit) / float64(time.Second))
+		return time.Unix(int64(sec), int64(math.Round(frac*1e9))).UTC(), nil
+	}
+
+	str, ok := raw.(string)
+	if !ok {
+		return time.Time{}, fmt.Errorf("timestamp '%v' is not a string", raw)
+	}
+	layout := c.Format
+	if strings.EqualFold(layout, TIME_FORMAT_RFC3339) {
+		layout = time.RFC3339Nano
+	}
+	t, err := time.ParseInLocation(layout, strings.TrimSpace(str), c.location)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid timestamp '%s': %w", str, err)
+	}
+	return t.UTC(), nil
+}
+
+// formatTime formats a time in one of the timestamp formats, the reverse of parse
+func formatTime(t time.Time, format string) string {
+	switch format {
+	case TIME_FORMAT_EPOCH_S:
+		return strconv.FormatInt(t.Unix(), 10)
+	case TIME_FORMAT_EPOCH_MS:
+		return strconv.FormatInt(t.UnixMilli(), 10)
+	case TIME_FORMAT_EPOCH_US:
+		return strconv.FormatInt(t.UnixMicro(), 10)
+	case TIME_FORMAT_EPOCH_NS:
+		return strconv.FormatInt(t.UnixNano(), 10)
+	case "", TIME_FORMAT_RFC3339:
+		return t.UTC().Format(time.RFC3339)
+	}
+	return t.UTC().Format(format)
+}
@@ -0,0 +1,51 @@
+package restapireceiver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimestamp_Parse(t *testing.T) {
+	expected := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		desc     TimestampDescription
+		raw      any
+		expected time.Time
+		errMsg   string
+	}{
+		{name: "RFC3339", desc: TimestampDescription{}, raw: "2024-05-01T10:00:00Z", expected: expected},
+		{name: "RFC3339Offset", desc: TimestampDescription{}, raw: "2024-05-01T12:00:00+02:00", expected: expected},
+		{name: "EpochSeconds", desc: TimestampDescription{Format: TIME_FORMAT_EPOCH_S}, raw: 1714557600.0, expected: expected},
+		{name: "EpochMillis", desc: TimestampDescription{Format: TIME_FORMAT_EPOCH_MS}, raw: 1714557600000.0, expected: expected},
+		{name: "EpochMillisString", desc: TimestampDescription{Format: TIME_FORMAT_EPOCH_MS}, raw: "1714557600123", expected: expected.Add(123 * time.Millisecond)},
+		{name: "EpochNanosString", desc: TimestampDescription{Format: TIME_FORMAT_EPOCH_NS}, raw: "1714557600000000001", expected: expected.Add(1)},
+		{name: "Layout", desc: TimestampDescription{Format: "2006-01-02 15:04:05"}, raw: "2024-05-01 10:00:00", expected: expected},
+		{name: "LayoutTimezone", desc: TimestampDescription{Format: "2006-01-02 15:04:05", Timezone: "Europe/Berlin"}, raw: "2024-05-01 12:00:00", expected: expected},
+		{name: "NotNumeric", desc: TimestampDescription{Format: TIME_FORMAT_EPOCH_S}, raw: "yesterday", errMsg: "invalid epoch_s timestamp: value 'yesterday' is not numeric"},
+		{name: "NotString", desc: TimestampDescription{}, raw: 12.0, errMsg: "timestamp '12' is not a string"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.desc.Field = "ts"
+			c, errs := compileTimestamp(&tt.desc)
+			require.Empty(t, errs)
+			ts, err := c.parse(tt.raw)
+			if tt.errMsg != "" {
+				assert.EqualError(t, err, tt.errMsg)
+			} else {
+				assert.NoError(t, err)
+				assert.True(t, tt.expected.Equal(ts), "expected %v, got %v", tt.expected, ts)
+			}
+		})
+	}
+}
+
+func TestCompileTimestamp_Errors(t *testing.T) {
+	_, errs := compileTimestamp(&TimestampDescription{Timezone: "Mars/Olympus"})
+	assert.Equal(t, []string{"'field' is required", "invalid timezone 'Mars/Olympus'"}, errs)
+}
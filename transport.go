@@ -0,0 +1,45 @@
+package restapireceiver
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+)
+
+const (
+	UNIX_SOCKET_SCHEME = "unix://"
+
+	// host used in the URLs of requests sent over a Unix socket
+	unixSocketHost = "localhost"
+)
+
+// unixSocketPath returns the socket path of a `unix:///path/to.sock` endpoint
+func unixSocketPath(endpoint string) (string, bool) {
+	if !strings.HasPrefix(endpoint, UNIX_SOCKET_SCHEME) {
+		return "", false
+	}
+	return strings.TrimPrefix(endpoint, UNIX_SOCKET_SCHEME), true
+}
+
+func validateEndpoint(endpoint string) []string {
+	if path, ok := unixSocketPath(endpoint); ok && !strings.HasPrefix(path, "/") {
+		return []string{"'endpoint' must be an absolute socket path like unix:///var/run/api.sock"}
+	}
+	return nil
+}
+
+// newTransport creates the transport for requests to the configured endpoint.
+// Requests to a Unix socket endpoint are dialed to the socket, whatever the host of their URL.
+func newTransport(cfg *Config) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = cfg.Proxy.proxyFunc()
+	if path, ok := unixSocketPath(cfg.Endpoint); ok {
+		dialer := &net.Dialer{}
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "unix", path)
+		}
+		transport.Proxy = nil
+	}
+	return transport
+}
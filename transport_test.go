@@ -0,0 +1,70 @@
+package restapireceiver
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newUnixSocketServer serves the handler on a socket in a short temporary directory,
+// socket paths are limited to about 100 characters
+func newUnixSocketServer(t *testing.T, handler http.Handler) string {
+	dir, err := os.MkdirTemp("", "restapi")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	path := filepath.Join(dir, "api.sock")
+	listener, err := net.Listen("unix", path)
+	require.NoError(t, err)
+	server := &http.Server{Handler: handler}
+	go server.Serve(listener)
+	t.Cleanup(func() { server.Close() })
+	return UNIX_SOCKET_SCHEME + path
+}
+
+func TestNewTransport_UnixSocket(t *testing.T) {
+	endpoint := newUnixSocketServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/containers/json", r.URL.Path)
+		assert.Equal(t, unixSocketHost, r.Host)
+		w.Write([]byte(`{"count": 2}`))
+	}))
+
+	helper := NewHttpClientHelper()
+	helper.Client = &http.Client{Transport: newTransport(&Config{Endpoint: endpoint})}
+	req, err := helper.NewGetRequest(BuildUrl(endpoint, "/containers/json"))
+	require.NoError(t, err)
+	response, err := helper.ExecuteJsonRequest(req)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"count": float64(2)}, response)
+}
+
+func TestScraper_ScrapeUnixSocket(t *testing.T) {
+	endpoint := newUnixSocketServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Empty(t, r.Header.Get(HEADER_KEY_AUTHORIZATION))
+		w.Write([]byte(`{"name": "docker", "containers": 5}`))
+	}))
+
+	cfg := &Config{Endpoint: endpoint, Description: Description{Endpoints: []EndpointDescription{{
+		Path: "/info",
+		Resources: []ResourceDescription{{
+			Attributes: map[string]string{"name": "name"},
+			Metrics:    []MetricDescription{{Name: "containers", Field: "containers"}},
+		}},
+	}}}}
+	require.NoError(t, cfg.Validate())
+	s := newTestScraper(t, cfg)
+	metrics, err := s.scrape(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, metrics.MetricCount())
+}
+
+func TestValidateEndpoint(t *testing.T) {
+	assert.Empty(t, validateEndpoint("unix:///var/run/docker.sock"))
+	assert.Empty(t, validateEndpoint("localhost:10000"))
+	assert.Equal(t, []string{"'endpoint' must be an absolute socket path like unix:///var/run/api.sock"}, validateEndpoint("unix://docker.sock"))
+}
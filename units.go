@@ -0,0 +1,172 @@
+package restapireceiver
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// unitDefinition relates a UCUM unit to the base unit of its dimension
+type unitDefinition struct {
+	dimension string
+	factor    float64
+}
+
+// knownUnits lists the UCUM units that can be converted into each other
+var knownUnits = map[string]unitDefinition{
+	"1": {"ratio", 1},
+	"%": {"ratio", 0.01},
+
+	"bit":  {"information", 0.125},
+	"kbit": {"information", 1e3 / 8},
+	"Mbit": {"information", 1e6 / 8},
+	"Gbit": {"information", 1e9 / 8},
+	"By":   {"information", 1},
+	"kBy":  {"information", 1e3},
+	"MBy":  {"information", 1e6},
+	"GBy":  {"information", 1e9},
+	"TBy":  {"information", 1e12},
+	"PBy":  {"information", 1e15},
+	"KiBy": {"information", 1 << 10},
+	"MiBy": {"information", 1 << 20},
+	"GiBy": {"information", 1 << 30},
+	"TiBy": {"information", 1 << 40},
+	"PiBy": {"information", 1 << 50},
+
+	"ns":  {"time", 1e-9},
+	"us":  {"time", 1e-6},
+	"ms":  {"time", 1e-3},
+	"s":   {"time", 1},
+	"min": {"time", 60},
+	"h":   {"time", 3600},
+	"d":   {"time", 86400},
+	"wk":  {"time", 7 * 86400},
+
+	"Hz":  {"frequency", 1},
+	"kHz": {"frequency", 1e3},
+	"MHz": {"frequency", 1e6},
+	"GHz": {"frequency", 1e9},
+
+	"W":  {"power", 1},
+	"kW": {"power", 1e3},
+
+	"Cel": {"temperature", 1},
+}
+
+// unitAliases maps human readable units found in API payloads to UCUM units.
+// "m" is read as minutes because quantity strings are mostly durations such as "1h30m".
+var unitAliases = map[string]string{
+	"b": "bit", "bits": "bit", "Kb": "kbit", "kb": "kbit", "Mb": "Mbit", "Gb": "Gbit",
+	"B": "By", "bytes": "By", "byte": "By",
+	"KB": "kBy", "kB": "kBy", "MB": "MBy", "GB": "GBy", "TB": "TBy", "PB": "PBy",
+	"KiB": "KiBy", "MiB": "MiBy", "GiB": "GiBy", "TiB": "TiBy", "PiB": "PiBy",
+	"µs": "us", "μs": "us", "sec": "s", "secs": "s", "m": "min", "mins": "min", "hr": "h", "hrs": "h",
+	"days": "d", "day": "d", "w": "wk",
+	"percent": "%", "°C": "Cel",
+}
+
+// canonicalUnit returns the UCUM code for a unit or alias
+func canonicalUnit(unit string) string {
+	if alias, ok := unitAliases[unit]; ok {
+		return alias
+	}
+	return unit
+}
+
+// lookupUnit resolves a unit, including simple quotients such as `By/s` or `MiBy/min`
+func lookupUnit(unit string) (unitDefinition, bool) {
+	if def, ok := knownUnits[canonicalUnit(unit)]; ok {
+		return def, true
+	}
+	if num, den, found := strings.Cut(unit, "/"); found {
+		n, ok1 := knownUnits[canonicalUnit(num)]
+		d, ok2 := knownUnits[canonicalUnit(den)]
+		if ok1 && ok2 {
+			return unitDefinition{dimension: n.dimension + "/" + d.dimension, factor: n.factor / d.factor}, true
+		}
+	}
+	return unitDefinition{}, false
+}
+
+// checkUnitConversion reports whether values in unit `from` can be converted to unit `to`
+func checkUnitConversion(from, to string) error {
+	if canonicalUnit(from) == canonicalUnit(to) {
+		return nil
+	}
+	f, ok := lookupUnit(from)
+	if !ok {
+		return fmt.Errorf("unknown unit '%s'", from)
+	}
+	t, ok := lookupUnit(to)
+	if !ok {
+		return fmt.Errorf("unknown unit '%s'", to)
+	}
+	if f.dimension != t.dimension || f.dimension == "temperature" {
+		return fmt.Errorf("cannot convert '%s' to '%s'", from, to)
+	}
+	return nil
+}
+
+// convertUnit converts a value between two units of the same dimension
+func convertUnit(value float64, from, to string) (float64, error) {
+	if err := checkUnitConversion(from, to); err != nil {
+		return 0, err
+	}
+	if canonicalUnit(from) == canonicalUnit(to) {
+		return value, nil
+	}
+	f, _ := lookupUnit(from)
+	t, _ := lookupUnit(to)
+	return value * f.factor / t.factor, nil
+}
+
+// parseQuantity parses human readable quantities such as "12.5 GiB", "250ms" or "3d4h".
+// It returns the value and its UCUM unit; plain numbers return an empty unit.
+// Durations made of several parts are summed and returned in seconds.
+func parseQuantity(s string) (float64, string, error) {
+	s = strings.TrimSpace(s)
+	if v, err := strconv.ParseFloat(s, 64); err == nil {
+		return v, "", nil
+	}
+
+	type part struct {
+		value float64
+		unit  string
+	}
+	var parts []part
+	rest := s
+	for rest != "" {
+		i := 0
+		for i < len(rest) && (rest[i] >= '0' && rest[i] <= '9' || rest[i] == '.' || (i == 0 && (rest[i] == '-' || rest[i] == '+'))) {
+			i++
+		}
+		v, err := strconv.ParseFloat(rest[:i], 64)
+		if err != nil {
+			return 0, "", fmt.Errorf("invalid quantity '%s'", s)
+		}
+		rest = strings.TrimLeft(rest[i:], " ")
+		j := 0
+		for j < len(rest) && !(rest[j] >= '0' && rest[j] <= '9') && rest[j] != ' ' {
+			j++
+		}
+		unit := canonicalUnit(rest[:j])
+		if _, ok := lookupUnit(unit); !ok {
+			return 0, "", fmt.Errorf("unknown unit '%s' in quantity '%s'", rest[:j], s)
+		}
+		parts = append(parts, part{value: v, unit: unit})
+		rest = strings.TrimLeft(rest[j:], " ")
+	}
+	if len(parts) == 1 {
+		return parts[0].value, parts[0].unit, nil
+	}
+
+	total := 0.0
+	for _, p := range parts {
+		def, _ := lookupUnit(p.unit)
+		if def.dimension != "time" {
+			return 0, "", fmt.Errorf("only durations may have several parts, got '%s'", s)
+		}
+		total += p.value * def.factor
+	}
+	return total, "s", nil
+}
@@ -0,0 +1,68 @@
+package restapireceiver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvertUnit(t *testing.T) {
+	tests := []struct {
+		value    float64
+		from, to string
+		expected float64
+		errMsg   string
+	}{
+		{value: 2048, from: "KiBy", to: "MiBy", expected: 2},
+		{value: 1, from: "GiB", to: "By", expected: 1 << 30},
+		{value: 8, from: "bit", to: "By", expected: 1},
+		{value: 1500, from: "ms", to: "s", expected: 1.5},
+		{value: 2, from: "h", to: "min", expected: 120},
+		{value: 50, from: "%", to: "1", expected: 0.5},
+		{value: 60, from: "MiBy/min", to: "MiBy/s", expected: 1},
+		{value: 3, from: "{requests}", to: "{requests}", expected: 3},
+		{value: 1, from: "By", to: "s", errMsg: "cannot convert 'By' to 's'"},
+		{value: 1, from: "Cel", to: "W", errMsg: "cannot convert 'Cel' to 'W'"},
+		{value: 1, from: "{requests}", to: "1", errMsg: "unknown unit '{requests}'"},
+	}
+
+	for _, tt := range tests {
+		v, err := convertUnit(tt.value, tt.from, tt.to)
+		if tt.errMsg != "" {
+			assert.EqualError(t, err, tt.errMsg)
+		} else {
+			assert.NoError(t, err)
+			assert.InDelta(t, tt.expected, v, 1e-9, "%s -> %s", tt.from, tt.to)
+		}
+	}
+}
+
+func TestParseQuantity(t *testing.T) {
+	tests := []struct {
+		input  string
+		value  float64
+		unit   string
+		errMsg string
+	}{
+		{input: "42", value: 42, unit: ""},
+		{input: "12.5 GiB", value: 12.5, unit: "GiBy"},
+		{input: "250ms", value: 250, unit: "ms"},
+		{input: "3d4h", value: 3*86400 + 4*3600, unit: "s"},
+		{input: "1h 30m", value: 5400, unit: "s"},
+		{input: "-5 %", value: -5, unit: "%"},
+		{input: "12 parsecs", errMsg: "unknown unit 'parsecs' in quantity '12 parsecs'"},
+		{input: "1GiB 2MiB", errMsg: "only durations may have several parts, got '1GiB 2MiB'"},
+		{input: "fast", errMsg: "invalid quantity 'fast'"},
+	}
+
+	for _, tt := range tests {
+		v, unit, err := parseQuantity(tt.input)
+		if tt.errMsg != "" {
+			assert.EqualError(t, err, tt.errMsg)
+		} else {
+			assert.NoError(t, err)
+			assert.Equal(t, tt.value, v, tt.input)
+			assert.Equal(t, tt.unit, unit, tt.input)
+		}
+	}
+}